@@ -7,6 +7,7 @@ import (
 
 	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
 	"github.com/abhinav/tmux-fastcopy/internal/log"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
 	shellwords "github.com/mattn/go-shellwords"
 	"go.uber.org/multierr"
 )
@@ -25,6 +26,11 @@ type actionFactory struct {
 	Log     *log.Logger
 	Environ func() []string
 	Getwd   func() (string, error)
+
+	// Tmux runs the builtin actions that talk to tmux.
+	// This is nil outside tmux.
+	Tmux    tmux.Driver
+	Version tmux.Version
 }
 
 type newActionRequest struct {
@@ -32,6 +38,9 @@ type newActionRequest struct {
 	// It should use "{}" as an argument to reference the selected text.
 	// If no "{}" is present, the selection will be sent to the command
 	// over stdin.
+	//
+	// Actions starting with "builtin:" are handled by tmux-fastcopy
+	// instead. See newBuiltin.
 	Action string
 
 	// Dir is the working directory to run the command in.
@@ -48,7 +57,14 @@ type newActionRequest struct {
 // The string is a multi-word shell command. It should use "{}" as an argument
 // to reference the selected text. If no "{}" is present, the selection will be
 // sent to the command over stdin.
+//
+// Strings starting with "builtin:" name actions that tmux-fastcopy
+// implements itself.
 func (f *actionFactory) New(req newActionRequest) (action, error) {
+	if name, ok := strings.CutPrefix(req.Action, _builtinPrefix); ok {
+		return f.newBuiltin(name, req)
+	}
+
 	args, err := shellwords.Parse(req.Action)
 	if err != nil {
 		return nil, err
@@ -5,10 +5,35 @@ import (
 	"flag"
 	"fmt"
 	"sort"
+	"strings"
+	"unicode"
 )
 
 const _defaultAlphabet alphabet = "abcdefghijklmnopqrstuvwxyz"
 
+// _alphabetPresets maps the names of predefined alphabets to their letters.
+//
+// Letters are listed in the order they appear on the keyboard, left to right.
+var _alphabetPresets = map[string]alphabet{
+	"qwerty-home":  "asdfghjkl",
+	"qwerty-left":  "qwertasdfgzxcvb",
+	"dvorak-home":  "aoeuidhtns",
+	"colemak-home": "arstdhneio",
+	"colemak-dh":   "arstgmneio",
+	"numeric":      "0123456789",
+}
+
+// _overlayKeys maps runes that are bound to other operations inside the
+// overlay to the names of those keys. These can't be used as labels.
+var _overlayKeys = map[rune]string{
+	'\t':   "Tab",
+	'\r':   "Enter",
+	'\n':   "Enter",
+	'\x1b': "Escape",
+	'\b':   "Backspace",
+	'\x7f': "Backspace",
+}
+
 type alphabet string
 
 var _ flag.Value = (*alphabet)(nil)
@@ -18,11 +43,21 @@ func (al *alphabet) String() string {
 }
 
 func (al *alphabet) Set(alpha string) error {
-	*al = alphabet(alpha)
+	*al = alphabet(alpha).resolve()
 	return al.Validate()
 }
 
+// resolve returns the letters of the preset with this name, if any.
+// Otherwise, it returns the alphabet unchanged.
+func (al alphabet) resolve() alphabet {
+	if preset, ok := _alphabetPresets[string(al)]; ok {
+		return preset
+	}
+	return al
+}
+
 func (al alphabet) Validate() error {
+	al = al.resolve()
 	if len(al) < 2 {
 		return errors.New("alphabet must have at least two items")
 	}
@@ -40,13 +75,56 @@ func (al alphabet) Validate() error {
 		return nil // success
 	}
 
-	dlist := make([]rune, 0, len(dupes))
-	for r := range dupes {
-		dlist = append(dlist, r)
+	return fmt.Errorf("alphabet has duplicates: %q", sortedRunes(dupes))
+}
+
+// Warnings reports problems with the alphabet that don't prevent its use,
+// but are likely to make some labels unusable.
+func (al alphabet) Warnings() []string {
+	var (
+		warnings []string
+		upper    = make(map[rune]struct{})
+		bound    = make(map[rune]struct{})
+	)
+	for _, r := range al.resolve() {
+		if _, ok := _overlayKeys[r]; ok {
+			bound[r] = struct{}{}
+		}
+		if unicode.IsUpper(r) {
+			upper[r] = struct{}{}
+		}
 	}
-	sort.Slice(dlist, func(i, j int) bool {
-		return dlist[i] < dlist[j]
-	})
 
-	return fmt.Errorf("alphabet has duplicates: %q", dlist)
+	if len(bound) > 0 {
+		rs := sortedRunes(bound)
+		names := make([]string, len(rs))
+		for i, r := range rs {
+			names[i] = _overlayKeys[r]
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"alphabet has keys used by the overlay: %q (%v): "+
+				"labels using these cannot be selected",
+			rs, strings.Join(names, ", ")))
+	}
+
+	if len(upper) > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"alphabet has uppercase letters: %q: "+
+				"these are typed as lowercase letters with Shift pressed, "+
+				"so labels using these cannot be selected",
+			sortedRunes(upper)))
+	}
+
+	return warnings
+}
+
+func sortedRunes(set map[rune]struct{}) []rune {
+	rs := make([]rune, 0, len(set))
+	for r := range set {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i] < rs[j]
+	})
+	return rs
 }
@@ -13,6 +13,7 @@ func TestValidateAlphabet(t *testing.T) {
 	tests := []struct {
 		desc    string
 		give    string
+		want    alphabet // if different from give
 		wantErr string
 	}{
 		{
@@ -33,6 +34,11 @@ func TestValidateAlphabet(t *testing.T) {
 			give:    "asdffghhjjkl",
 			wantErr: "alphabet has duplicates: ['f' 'h' 'j']",
 		},
+		{
+			desc: "preset",
+			give: "dvorak-home",
+			want: "aoeuidhtns",
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +50,9 @@ func TestValidateAlphabet(t *testing.T) {
 			err := alpha.Set(tt.give)
 			if len(tt.wantErr) == 0 {
 				assert.NoError(t, err)
+				if len(tt.want) > 0 {
+					assert.Equal(t, tt.want, alpha)
+				}
 				return
 			}
 
@@ -52,3 +61,62 @@ func TestValidateAlphabet(t *testing.T) {
 		})
 	}
 }
+
+func TestAlphabetPresets(t *testing.T) {
+	t.Parallel()
+
+	for name, preset := range _alphabetPresets {
+		name, preset := name, preset
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.NoError(t, preset.Validate())
+			assert.Empty(t, preset.Warnings())
+			assert.NoError(t, alphabet(name).Validate())
+		})
+	}
+}
+
+func TestAlphabetWarnings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give alphabet
+		want []string
+	}{
+		{desc: "default", give: _defaultAlphabet},
+		{desc: "preset", give: "qwerty-home"},
+		{
+			desc: "uppercase",
+			give: "abCD",
+			want: []string{"alphabet has uppercase letters: ['C' 'D']"},
+		},
+		{
+			desc: "overlay keys",
+			give: "ab\t\r",
+			want: []string{"alphabet has keys used by the overlay: ['\\t' '\\r'] (Tab, Enter)"},
+		},
+		{
+			desc: "both",
+			give: "aB\x1b",
+			want: []string{
+				"alphabet has keys used by the overlay: ['\\x1b'] (Escape)",
+				"alphabet has uppercase letters: ['B']",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.give.Warnings()
+			require.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				assert.Contains(t, got[i], want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
 	"github.com/abhinav/tmux-fastcopy/internal/log"
@@ -9,140 +10,319 @@ import (
 	"github.com/abhinav/tmux-fastcopy/internal/ui"
 	tcell "github.com/gdamore/tcell/v3"
 	tcolor "github.com/gdamore/tcell/v3/color"
+	"go.uber.org/multierr"
 )
 
 // app implements the main fastcopy application logic. It assumes that it's
 // running inside a tmux window that it has full control over. (wrapper takes
 // care of ensuring that.)
 type app struct {
+	Stdout    io.Writer
 	Log       *log.Logger
 	Tmux      tmux.Driver
 	NewAction func(newActionRequest) (action, error)
 
+	// Version of tmux, used to decide which features are available.
+	Version tmux.Version
+
+	// Directory in which persistent state is stored.
+	// Features that need it are disabled if this is empty.
+	StateDir string
+
 	NewScreen func() (tcell.Screen, error) // == tcell.NewScreen
+
+	// File to write the result to for the wrapper, if any.
+	ResultFile string
+
+	// Whether to load tmux options before running. The wrapper normally
+	// takes care of this, so this is only needed if we're not wrapped.
+	LoadOptions bool
 }
 
 // Run runs the application with the provided configuration.
 func (app *app) Run(cfg *config) error {
-	cfg.FillFrom(defaultConfig(cfg))
+	sel, err := app.run(cfg)
+	if len(app.ResultFile) > 0 {
+		// Let the wrapper know how it went.
+		return multierr.Append(err, writeResult(app.ResultFile, newResult(sel, err)))
+	}
 
-	matcher := make(matcher, 0, len(cfg.Regexes))
-	for name, reg := range cfg.Regexes {
-		m, err := compileRegexpMatcher(name, reg)
-		if err != nil {
-			return fmt.Errorf("compile regex %q: %v", name, reg)
-		}
-		if m != nil {
-			matcher = append(matcher, m)
+	if err == nil && cfg.Print && len(sel.Text) == 0 {
+		return _errCancelled
+	}
+	return err
+}
+
+func (app *app) run(cfg *config) (fastcopy.Selection, error) {
+	if app.LoadOptions {
+		if err := cfg.loadOptions(app.Tmux); err != nil {
+			return fastcopy.Selection{}, err
 		}
 	}
+	cfg.FillFrom(defaultConfig())
+	for _, w := range cfg.Alphabet.Warnings() {
+		app.Log.Infof("%v", w)
+	}
 
-	targetPane, err := tmux.InspectPane(app.Tmux, cfg.Pane)
+	if cfg.HistoryLines < 0 {
+		return fastcopy.Selection{}, fmt.Errorf("history-lines must not be negative: %v", cfg.HistoryLines)
+	}
+
+	matcher, err := cfg.newMatcher()
 	if err != nil {
-		return fmt.Errorf("inspect pane %q: %v", cfg.Pane, err)
+		return fastcopy.Selection{}, err
 	}
 
-	// Size specification in new-session doesn't always take and causes
-	// flickers when swapping panes around. Make sure that the window is
-	// right-sized.
-	myPane, err := tmux.InspectPane(app.Tmux, "")
+	altAction, leaderActions, err := cfg.actionKeys()
 	if err != nil {
-		return err
+		return fastcopy.Selection{}, err
+	}
+	if err := cfg.checkMenus(); err != nil {
+		return fastcopy.Selection{}, err
 	}
 
-	if myPane.Width != targetPane.Width || myPane.Height != targetPane.Height {
-		resizeReq := tmux.ResizeWindowRequest{
-			Window: myPane.WindowID,
-			Width:  targetPane.Width,
-			Height: targetPane.Height,
-		}
-		if err := app.Tmux.ResizeWindow(resizeReq); err != nil {
-			app.Log.Errorf("unable to resize %q: %v",
-				myPane.WindowID, err)
-			// Not the end of the world. Keep going.
-		}
+	if name := cfg.AutoSelectRegex; len(name) > 0 && len(cfg.Regexes[name]) == 0 {
+		app.Log.Infof("auto-select regex %q is not defined", name)
 	}
 
-	creq := tmux.CapturePaneRequest{Pane: targetPane.ID}
-	if targetPane.Mode == tmux.CopyMode {
-		// If the pane is in copy-mode, the default capture-pane will
-		// capture the bottom of the screen that would normally be
-		// visible if not in copy mode. Supply positions to capture for
-		// that case.
-		creq.StartLine = -targetPane.ScrollPosition
-		creq.EndLine = creq.StartLine + targetPane.Height - 1
+	targetPane, err := tmux.InspectPane(app.Tmux, cfg.Pane)
+	if err != nil {
+		return fastcopy.Selection{}, fmt.Errorf("inspect pane %q: %v", cfg.Pane, err)
 	}
 
-	bs, err := app.Tmux.CapturePane(creq)
+	captured, err := captureText(app.Tmux, app.Version, cfg, matcher, targetPane)
 	if err != nil {
-		return fmt.Errorf("capture pane %q: %v", cfg.Pane, err)
+		return fastcopy.Selection{}, err
+	}
+
+	history := openSelectionHistory(app.Log, cfg.SelectionHistory, app.StateDir)
+
+	// Decide whether we need the UI at all before we touch any panes.
+	if cfg.AutoSelect || len(cfg.AutoSelectRegex) > 0 {
+		if sel, ok := autoSelect(captured.Text, captured.Matches, cfg.AutoSelectRegex); ok {
+			app.Log.Debugf("auto-selected %q", sel.Text)
+			history.Record(sel)
+			return sel, app.finish(cfg, captured.PaneOf(sel.Text), sel)
+		}
 	}
 
-	screen, err := app.NewScreen()
+	var (
+		screen tcell.Screen
+		myPane *tmux.PaneInfo
+	)
+	switch {
+	case cfg.Keys.IsSet():
+		// Scripted keys don't need a terminal or a visible overlay.
+		screen, err = newHeadlessScreen(captured.Width, captured.Height)
+	case cfg.Popup:
+		// The wrapper has already placed our popup over the target
+		// pane at the right size, so there's nothing to move around.
+		screen, err = app.NewScreen()
+	default:
+		myPane, err = app.fitWindow(captured.Width, captured.Height)
+		if err == nil {
+			screen, err = app.NewScreen()
+		}
+	}
 	if err != nil {
-		return err
+		return fastcopy.Selection{}, err
 	}
 
 	if err := screen.Init(); err != nil {
-		return err
+		return fastcopy.Selection{}, err
 	}
 	defer screen.Fini()
 
+	labels := openLabelMemory(app.Log, cfg.LabelCache, app.StateDir, targetPane.ID)
+
 	ctrl := ctrl{
-		Screen:   screen,
-		Log:      app.Log,
-		Text:     string(bs),
-		Alphabet: []rune(cfg.Alphabet),
-		Matcher:  matcher,
+		Screen:         screen,
+		Log:            app.Log,
+		Text:           captured.Text,
+		Alphabet:       []rune(cfg.Alphabet),
+		Matches:        captured.Matches,
+		PreviousLabels: labels.Labels(),
+		LabelStrategy:  cfg.LabelStrategy.Strategy(),
+		HintWeight:     history.Weigh(),
+		AltAction:      altAction,
+		LeaderActions:  leaderActions,
+	}
+	// Scripted keys end with Escape,
+	// which would close the menu without choosing anything.
+	if !cfg.Print && !cfg.Keys.IsSet() {
+		ctrl.Menu = cfg.menuFor
 	}
 	ctrl.Init()
 
+	if myPane != nil {
+		restore, err := app.swapIn(targetPane, myPane, captured.Window)
+		if err != nil {
+			return fastcopy.Selection{}, err
+		}
+		defer restore()
+	}
+	if cfg.Keys.IsSet() {
+		stop := make(chan struct{})
+		defer close(stop)
+		go postKeys(screen, cfg.Keys.Events(), stop)
+	}
+
+	selection, err := ctrl.Wait()
+	if err != nil {
+		return fastcopy.Selection{}, err
+	}
+	labels.Remember(ctrl.Labels())
+	if len(selection.Matchers) > 0 {
+		history.Record(selection)
+	}
+
+	return selection, app.finish(cfg, captured.PaneOf(selection.Text), selection)
+}
+
+// fitWindow resizes the window that we're running inside to the given size,
+// and reports information about our own pane.
+func (app *app) fitWindow(width, height int) (*tmux.PaneInfo, error) {
+	// Size specification in new-session doesn't always take and causes
+	// flickers when swapping panes around. Make sure that the window is
+	// right-sized.
+	myPane, err := tmux.InspectPane(app.Tmux, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if myPane.Width != width || myPane.Height != height {
+		resizeReq := tmux.ResizeWindowRequest{
+			Window: myPane.WindowID,
+			Width:  width,
+			Height: height,
+		}
+		if err := app.Tmux.ResizeWindow(resizeReq); err != nil {
+			app.Log.Errorf("unable to resize %q: %v",
+				myPane.WindowID, err)
+			// Not the end of the world. Keep going.
+		}
+	}
+
+	return myPane, nil
+}
+
+// swapIn swaps our pane into the place of the target pane so that the user
+// sees the overlay. If wholeWindow is set, our pane is zoomed to cover the
+// whole window. The returned function reverses this.
+func (app *app) swapIn(targetPane, myPane *tmux.PaneInfo, wholeWindow bool) (restore func(), err error) {
+	// If the window was zoomed, zoom the swapped pane as well. In Tmux 3.1
+	// or newer, we can use the '-Z' flag of swap-pane, but that's not
+	// available in older versions so we toggle the zoom ourselves there.
+	keepZoom := app.Version.SupportsSwapPaneZoom()
+	toggleZoom := targetPane.WindowZoomed && !keepZoom
+
 	if err := app.Tmux.SwapPane(tmux.SwapPaneRequest{
 		Source:      targetPane.ID,
 		Destination: myPane.ID,
+		KeepZoom:    keepZoom,
 	}); err != nil {
-		return err
+		return nil, err
 	}
 
-	// If the window was zoomed, zoom the swapped pane as well. In Tmux 3.1
-	// or newer, we can use the '-Z' flag of swap-pane, but that's not
-	// available in older versions.
-	if targetPane.WindowZoomed {
+	if toggleZoom || wholeWindow {
 		_ = app.Tmux.ResizePane(tmux.ResizePaneRequest{
 			Target:     myPane.ID,
 			ToggleZoom: true,
 		})
+	}
 
-		defer func() {
+	return func() {
+		if wholeWindow {
+			// The window wasn't zoomed before, so unzoom it
+			// before the target pane is back in its place.
 			_ = app.Tmux.ResizePane(tmux.ResizePaneRequest{
-				Target:     targetPane.ID,
+				Target:     myPane.ID,
 				ToggleZoom: true,
 			})
-		}()
-	}
+		}
 
-	defer func() {
 		_ = app.Tmux.SwapPane(tmux.SwapPaneRequest{
 			Destination: targetPane.ID,
 			Source:      myPane.ID,
+			KeepZoom:    keepZoom,
 		})
-	}()
 
-	selection, err := ctrl.Wait()
-	if err != nil {
-		return err
+		if toggleZoom {
+			_ = app.Tmux.ResizePane(tmux.ResizePaneRequest{
+				Target:     targetPane.ID,
+				ToggleZoom: true,
+			})
+		}
+	}, nil
+}
+
+// capturePane captures the visible contents of the given pane, along with up
+// to historyLines lines of scrollback above it.
+func capturePane(driver tmux.Driver, version tmux.Version, pane *tmux.PaneInfo, historyLines int) (string, error) {
+	bs, err := driver.CapturePane(newCapturePaneRequest(version, pane, historyLines))
+	return string(bs), err
+}
+
+// newCapturePaneRequest builds a request to capture the visible contents of
+// the given pane, along with up to historyLines lines of scrollback above it.
+func newCapturePaneRequest(version tmux.Version, pane *tmux.PaneInfo, historyLines int) tmux.CapturePaneRequest {
+	req := tmux.CapturePaneRequest{
+		Pane: pane.ID,
+		// Join lines that wrapped at the edge of the pane so that
+		// matches may span them. matcher.MatchWrapped wraps them
+		// again at the same width so they show up where they were.
+		JoinLines: true,
+		// Drop trailing blanks where we can so that they don't end up
+		// in matches.
+		TrimTrailing: version.SupportsCapturePaneTrim(),
+	}
+	if pane.Mode == tmux.CopyMode {
+		// If the pane is in copy-mode, the default capture-pane will
+		// capture the bottom of the screen that would normally be
+		// visible if not in copy mode. Supply positions to capture for
+		// that case.
+		req.StartLine = -pane.ScrollPosition
+		req.EndLine = req.StartLine + pane.Height - 1
+	}
+	// Lines above the top of the pane are in the scrollback history.
+	// tmux stops at the start of the history if there isn't enough.
+	req.StartLine -= historyLines
+	return req
+}
+
+// finish hands off the selection made by the user: printing it for -print,
+// or running the action otherwise.
+func (app *app) finish(cfg *config, targetPane *tmux.PaneInfo, selection fastcopy.Selection) error {
+	if len(selection.Text) == 0 {
+		app.Log.Debugf("nothing selected")
+		return nil
 	}
 
-	actionStr := cfg.Action
-	if selection.Shift {
-		actionStr = cfg.ShiftAction
+	if cfg.Print {
+		// If we're wrapped, the wrapper prints the result.
+		if len(app.ResultFile) == 0 {
+			return printSelection(app.Stdout, cfg, selection)
+		}
+		return nil
 	}
 
+	return runAction(app.NewAction, cfg, targetPane, selection)
+}
+
+// runAction runs the action configured for the given selection
+// against the target pane.
+func runAction(
+	newAction func(newActionRequest) (action, error),
+	cfg *config,
+	targetPane *tmux.PaneInfo,
+	selection fastcopy.Selection,
+) error {
+	actionStr := cfg.actionFor(selection)
 	if len(actionStr) == 0 {
 		return nil
 	}
 
-	action, err := app.NewAction(newActionRequest{
+	action, err := newAction(newActionRequest{
 		Action:       actionStr,
 		Dir:          targetPane.CurrentPath,
 		TargetPaneID: targetPane.ID,
@@ -154,16 +334,71 @@ func (app *app) Run(cfg *config) error {
 	return action.Run(selection)
 }
 
+// actionFor reports the command that handles the given selection.
+//
+// Named actions requested with a key take precedence. Otherwise, if the
+// regexes that matched the selection have their own actions and all of them
+// agree, that action is used instead of the action or the shift action.
+// Regexes without their own action don't count.
+func (c *config) actionFor(selection fastcopy.Selection) string {
+	fallback, byRegex := c.Action, map[string]string(c.RegexActions)
+	switch name := selection.Action; name {
+	case "", _defaultActionName:
+		// Use the default action.
+	case fastcopy.ShiftAction:
+		fallback, byRegex = c.ShiftAction, c.RegexShiftActions
+	default:
+		return c.NamedActions[name]
+	}
+
+	var action string
+	for _, name := range selection.Matchers {
+		a := byRegex[name]
+		if len(a) == 0 {
+			continue
+		}
+		if len(action) > 0 && a != action {
+			// Regexes disagree on the action.
+			return fallback
+		}
+		action = a
+	}
+
+	if len(action) == 0 {
+		return fallback
+	}
+	return action
+}
+
 type ctrl struct {
 	Screen   tcell.Screen
 	Log      *log.Logger
 	Alphabet []rune
 	Text     string
-	Matcher  matcher
+	Matches  []fastcopy.Match
+
+	// Labels assigned in a prior run, if any.
+	PreviousLabels map[string]string
+
+	// Strategy used to generate labels.
+	LabelStrategy fastcopy.LabelStrategy
 
-	w   *fastcopy.Widget
-	ui  *ui.App
-	sel fastcopy.Selection
+	// Weighs hints, if set.
+	HintWeight func(string, []fastcopy.Match) int
+
+	// Actions requested with Alt and with leader keys, if any.
+	AltAction     string
+	LeaderActions map[string]string
+
+	// Menu reports the names of actions to choose from after a
+	// selection, if any. The selection is reported right away if this
+	// is unset or empty.
+	Menu func(fastcopy.Selection) []string
+
+	w    *fastcopy.Widget
+	menu *ui.Menu
+	ui   *ui.App
+	sel  fastcopy.Selection
 }
 
 func (c *ctrl) Init() {
@@ -172,10 +407,15 @@ func (c *ctrl) Init() {
 		Foreground(tcolor.White)
 
 	c.w = (&fastcopy.WidgetConfig{
-		Text:         c.Text,
-		Matches:      c.Matcher.Match(c.Text),
-		Handler:      c,
-		HintAlphabet: c.Alphabet,
+		Text:           c.Text,
+		Matches:        c.Matches,
+		Handler:        c,
+		HintAlphabet:   c.Alphabet,
+		PreviousLabels: c.PreviousLabels,
+		LabelStrategy:  c.LabelStrategy,
+		HintWeight:     c.HintWeight,
+		AltAction:      c.AltAction,
+		LeaderActions:  c.LeaderActions,
 		Style: fastcopy.Style{
 			Normal:         base,
 			Match:          base.Foreground(tcolor.Green),
@@ -187,8 +427,23 @@ func (c *ctrl) Init() {
 		},
 	}).Build()
 
+	c.menu = &ui.Menu{
+		// Ctrl-L switches between hints in place and hints in a list.
+		// Text captured from the scrollback history and long lists may
+		// not fit on the screen.
+		Child: &ui.Switch{
+			Key: tcell.KeyCtrlL,
+			Widgets: []ui.Widget{
+				&ui.ScrollView{Child: c.w},
+				&ui.ScrollView{Child: c.w.List(), FromTop: true},
+			},
+		},
+		Style:    base.Background(tcolor.White).Foreground(tcolor.Black),
+		KeyStyle: base.Background(tcolor.White).Foreground(tcolor.Red),
+	}
+
 	c.ui = &ui.App{
-		Root:   c.w,
+		Root:   c.menu,
 		Screen: c.Screen,
 		Log:    c.Log,
 	}
@@ -201,7 +456,24 @@ func (c *ctrl) Wait() (fastcopy.Selection, error) {
 	return c.sel, err
 }
 
+// Labels reports the labels assigned to text in the UI.
+func (c *ctrl) Labels() map[string]string {
+	return c.w.Labels()
+}
+
 func (c *ctrl) HandleSelection(sel fastcopy.Selection) {
+	// Offer a menu only if the user didn't already ask for an action.
+	if len(sel.Action) == 0 && c.Menu != nil {
+		if actions := c.Menu(sel); len(actions) > 0 {
+			c.menu.Show(sel.Text, menuItems(actions), func(item ui.MenuItem) {
+				sel.Action = item.Label
+				c.sel = sel
+				c.ui.Stop()
+			})
+			return
+		}
+	}
+
 	c.sel = sel
 	c.ui.Stop()
 }
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"path/filepath"
 	"testing"
 
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
 	"github.com/abhinav/tmux-fastcopy/internal/log/logtest"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	tcell "github.com/gdamore/tcell/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -44,3 +49,644 @@ func TestApp_Run_inspectPaneError(t *testing.T) {
 	require.Error(t, err, "run must fail")
 	assert.ErrorContains(t, err, "great sadness")
 }
+
+func TestApp_Run_autoSelect(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	// No calls to SwapPane, ResizeWindow, etc. are expected.
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+		Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+		Return([]byte("foo 1234 bar\n"), nil)
+
+	var (
+		gotReq newActionRequest
+		gotSel fastcopy.Selection
+	)
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(req newActionRequest) (action, error) {
+			gotReq = req
+			return actionFunc(func(sel fastcopy.Selection) error {
+				gotSel = sel
+				return nil
+			}), nil
+		},
+		NewScreen: func() (tcell.Screen, error) {
+			t.Error("screen must not be created")
+			return nil, errors.New("unexpected screen")
+		},
+	}).Run(&config{
+		Pane:       "42",
+		Action:     "pbcopy",
+		Regexes:    regexes{"int": `\d+`},
+		AutoSelect: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, newActionRequest{
+		Action:       "pbcopy",
+		Dir:          "/home/user",
+		TargetPaneID: "%42",
+	}, gotReq)
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "1234",
+		Matchers: []string{"int"},
+	}, gotSel)
+}
+
+type actionFunc func(fastcopy.Selection) error
+
+func (f actionFunc) Run(sel fastcopy.Selection) error {
+	return f(sel)
+}
+
+func TestApp_Run_keys(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	// No calls to SwapPane, ResizeWindow, etc. are expected.
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+		Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+		Return([]byte("foo 1234 bar 5678\n"), nil)
+
+	var gotSel fastcopy.Selection
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(req newActionRequest) (action, error) {
+			assert.Equal(t, "open", req.Action)
+			return actionFunc(func(sel fastcopy.Selection) error {
+				gotSel = sel
+				return nil
+			}), nil
+		},
+	}).Run(&config{
+		Pane:        "42",
+		Action:      "pbcopy",
+		ShiftAction: "open",
+		Alphabet:    "ab",
+		Regexes:     regexes{"int": `\d+`},
+		Keys:        "B",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "5678",
+		Matchers: []string{"int"},
+		Action:   fastcopy.ShiftAction,
+	}, gotSel)
+}
+
+func TestApp_Run_namedAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		keys keySequence
+		menu string // menu for the int regex
+		want string // action that was run
+	}{
+		{desc: "alt", keys: "<M-b>", want: "xdg-open {}"},
+		{desc: "leader", keys: ",b", want: "vim {}"},
+		{desc: "leader for shift", keys: ".b", want: "open"},
+		{desc: "default", keys: "b", want: "pbcopy"},
+		{desc: "menu", keys: "bo", menu: "edit open", want: "xdg-open {}"},
+		{desc: "menu/enter", keys: "b<Enter>", menu: "edit open", want: "vim {}"},
+		{desc: "menu/shift", keys: "bs", menu: "edit shift", want: "open"},
+		{desc: "menu/default", keys: "bd", menu: "edit default", want: "pbcopy"},
+		{desc: "menu/cancelled", keys: "b", menu: "edit"},
+		{desc: "menu/skipped with alt", keys: "<M-b>", menu: "edit", want: "xdg-open {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+			tmuxDriver.EXPECT().
+				DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+				Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+			tmuxDriver.EXPECT().
+				CapturePane(gomock.Any()).
+				Return([]byte("foo 1234 bar 5678\n"), nil)
+
+			stop := make(chan struct{})
+			defer close(stop)
+
+			// Type the keys into a simulated terminal instead of
+			// using -keys, which doesn't show menus.
+			var gotAction string
+			err := (&app{
+				Log:  logtest.NewLogger(t),
+				Tmux: tmuxDriver,
+				NewAction: func(req newActionRequest) (action, error) {
+					gotAction = req.Action
+					return actionFunc(func(fastcopy.Selection) error {
+						return nil
+					}), nil
+				},
+				NewScreen: func() (tcell.Screen, error) {
+					screen, err := newHeadlessScreen(80, 24)
+					if err == nil {
+						go postKeys(screen, tt.keys.Events(), stop)
+					}
+					return screen, err
+				},
+			}).Run(&config{
+				Pane:        "42",
+				Action:      "pbcopy",
+				ShiftAction: "open",
+				NamedActions: namedActions{
+					"open": "xdg-open {}",
+					"edit": "vim {}",
+				},
+				Bindings: actionBindings{
+					"open":  "alt",
+					"edit":  ",",
+					"shift": ".",
+				},
+				Alphabet:   "ab",
+				Regexes:    regexes{"int": `\d+`},
+				Popup:      true,
+				RegexMenus: regexMenus{"int": tt.menu},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, gotAction)
+		})
+	}
+}
+
+func TestApp_Run_keysSkipMenu(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+		Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(gomock.Any()).
+		Return([]byte("foo 1234 bar 5678\n"), nil)
+
+	var gotAction string
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(req newActionRequest) (action, error) {
+			gotAction = req.Action
+			return actionFunc(func(fastcopy.Selection) error {
+				return nil
+			}), nil
+		},
+	}).Run(&config{
+		Pane:         "42",
+		Action:       "pbcopy",
+		NamedActions: namedActions{"edit": "vim {}"},
+		Alphabet:     "ab",
+		Regexes:      regexes{"int": `\d+`},
+		Keys:         "b",
+		RegexMenus:   regexMenus{"int": "edit"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pbcopy", gotAction, "-keys must not wait for the menu")
+}
+
+func TestConfigActionFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := config{
+		Action:       "tmux load-buffer -",
+		ShiftAction:  "open",
+		NamedActions: namedActions{"edit": "vim {}"},
+		RegexActions: regexActions{
+			"url":    "xdg-open {}",
+			"gitsha": "git show {}",
+			"sha":    "git show {}",
+		},
+		RegexShiftActions: regexShiftActions{"url": "curl {}"},
+	}
+
+	tests := []struct {
+		desc string
+		give fastcopy.Selection
+		want string
+	}{
+		{
+			desc: "default",
+			give: fastcopy.Selection{Matchers: []string{"int"}},
+			want: "tmux load-buffer -",
+		},
+		{
+			desc: "regex",
+			give: fastcopy.Selection{Matchers: []string{"url"}},
+			want: "xdg-open {}",
+		},
+		{
+			desc: "regex/shift",
+			give: fastcopy.Selection{Matchers: []string{"url"}, Action: fastcopy.ShiftAction},
+			want: "curl {}",
+		},
+		{
+			desc: "regex/no shift action",
+			give: fastcopy.Selection{Matchers: []string{"gitsha"}, Action: fastcopy.ShiftAction},
+			want: "open",
+		},
+		{
+			desc: "regex/others without actions",
+			give: fastcopy.Selection{Matchers: []string{"gitsha", "int"}},
+			want: "git show {}",
+		},
+		{
+			desc: "regex/agree",
+			give: fastcopy.Selection{Matchers: []string{"gitsha", "sha"}},
+			want: "git show {}",
+		},
+		{
+			desc: "regex/disagree",
+			give: fastcopy.Selection{Matchers: []string{"gitsha", "url"}},
+			want: "tmux load-buffer -",
+		},
+		{
+			desc: "named",
+			give: fastcopy.Selection{Matchers: []string{"url"}, Action: "edit"},
+			want: "vim {}",
+		},
+		{
+			desc: "named default",
+			give: fastcopy.Selection{Matchers: []string{"url"}, Action: "default"},
+			want: "xdg-open {}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, cfg.actionFor(tt.give))
+		})
+	}
+}
+
+func TestApp_Run_windowScope(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+		Return([]byte("%42\t@1\t40\t24\tnormal-mode\t0\t0\t/home/user\t0\t0"), nil)
+	tmuxDriver.EXPECT().
+		ListPanes(gomock.Any()).
+		Return([]byte(
+			"%42\t@1\t40\t24\tnormal-mode\t0\t0\t/home/user\t0\t0\n"+
+				"%43\t@1\t39\t24\tnormal-mode\t0\t0\t/tmp\t41\t0\n"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+		Return([]byte("foo 1234\n"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%43", JoinLines: true}).
+		Return([]byte("bar 5678\n"), nil)
+
+	var gotSel fastcopy.Selection
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(req newActionRequest) (action, error) {
+			// The action runs for the pane the text came from.
+			assert.Equal(t, "%43", req.TargetPaneID)
+			assert.Equal(t, "/tmp", req.Dir)
+			return actionFunc(func(sel fastcopy.Selection) error {
+				gotSel = sel
+				return nil
+			}), nil
+		},
+	}).Run(&config{
+		Pane:     "42",
+		Action:   "pbcopy",
+		Alphabet: "ab",
+		Regexes:  regexes{"int": `\d+`},
+		Scope:    captureScopeWindow,
+		Keys:     "b",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "5678",
+		Matchers: []string{"int"},
+	}, gotSel)
+}
+
+func TestApp_Run_negativeHistoryLines(t *testing.T) {
+	t.Parallel()
+
+	err := (&app{Log: logtest.NewLogger(t)}).Run(&config{
+		Alphabet:     "ab",
+		HistoryLines: -1,
+	})
+	assert.ErrorContains(t, err, "history-lines must not be negative")
+}
+
+func TestCapturePane(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc         string
+		pane         tmux.PaneInfo
+		historyLines int
+		want         tmux.CapturePaneRequest
+	}{
+		{
+			desc: "visible",
+			pane: tmux.PaneInfo{ID: "%1", Height: 24},
+			want: tmux.CapturePaneRequest{Pane: "%1", JoinLines: true},
+		},
+		{
+			desc:         "history",
+			pane:         tmux.PaneInfo{ID: "%1", Height: 24},
+			historyLines: 500,
+			want:         tmux.CapturePaneRequest{Pane: "%1", JoinLines: true, StartLine: -500},
+		},
+		{
+			desc: "copy mode",
+			pane: tmux.PaneInfo{
+				ID:             "%1",
+				Height:         24,
+				Mode:           tmux.CopyMode,
+				ScrollPosition: 30,
+			},
+			want: tmux.CapturePaneRequest{Pane: "%1", JoinLines: true, StartLine: -30, EndLine: -7},
+		},
+		{
+			desc: "copy mode/history",
+			pane: tmux.PaneInfo{
+				ID:             "%1",
+				Height:         24,
+				Mode:           tmux.CopyMode,
+				ScrollPosition: 30,
+			},
+			historyLines: 500,
+			want:         tmux.CapturePaneRequest{Pane: "%1", JoinLines: true, StartLine: -530, EndLine: -7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+			tmuxDriver.EXPECT().
+				CapturePane(tt.want).
+				Return([]byte("foo\n"), nil)
+
+			got, err := capturePane(tmuxDriver, tmux.Version{Major: 3, Minor: 3}, &tt.pane, tt.historyLines)
+			require.NoError(t, err)
+			assert.Equal(t, "foo\n", got)
+		})
+	}
+}
+
+func TestApp_Run_popup(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	// The popup is already in place so no calls to SwapPane,
+	// ResizeWindow, etc. are expected.
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+		Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+		Return([]byte("foo 1234 bar 5678\n"), nil)
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var gotSel fastcopy.Selection
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(newActionRequest) (action, error) {
+			return actionFunc(func(sel fastcopy.Selection) error {
+				gotSel = sel
+				return nil
+			}), nil
+		},
+		NewScreen: func() (tcell.Screen, error) {
+			screen, err := newHeadlessScreen(80, 24)
+			if err == nil {
+				events, _ := parseKeys("a")
+				go postKeys(screen, events, stop)
+			}
+			return screen, err
+		},
+	}).Run(&config{
+		Pane:     "42",
+		Action:   "pbcopy",
+		Alphabet: "ab",
+		Regexes:  regexes{"int": `\d+`},
+		Popup:    true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "1234",
+		Matchers: []string{"int"},
+	}, gotSel)
+}
+
+func TestApp_Run_print(t *testing.T) {
+	t.Parallel()
+
+	newTmux := func(t *testing.T) *tmuxtest.MockDriver {
+		tmuxDriver := tmuxtest.NewMockDriver(gomock.NewController(t))
+		tmuxDriver.EXPECT().
+			DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+			Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+		tmuxDriver.EXPECT().
+			CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+			Return([]byte("foo 1234 bar deadbeef\n"), nil)
+		return tmuxDriver
+	}
+
+	newAction := func(t *testing.T) func(newActionRequest) (action, error) {
+		return func(newActionRequest) (action, error) {
+			t.Error("action must not run")
+			return nil, errors.New("unexpected action")
+		}
+	}
+
+	cfg := func() *config {
+		return &config{
+			Pane:       "42",
+			Action:     "pbcopy",
+			RegexOnly:  "gitsha",
+			AutoSelect: true,
+			Print:      true,
+		}
+	}
+
+	t.Run("unwrapped", func(t *testing.T) {
+		t.Parallel()
+
+		var stdout bytes.Buffer
+		err := (&app{
+			Stdout:    &stdout,
+			Log:       logtest.NewLogger(t),
+			Tmux:      newTmux(t),
+			NewAction: newAction(t),
+		}).Run(cfg())
+		require.NoError(t, err)
+		assert.Equal(t, "deadbeef\n", stdout.String())
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		t.Parallel()
+
+		resultFile := filepath.Join(t.TempDir(), "result")
+
+		var stdout bytes.Buffer
+		err := (&app{
+			Stdout:     &stdout,
+			Log:        logtest.NewLogger(t),
+			Tmux:       newTmux(t),
+			NewAction:  newAction(t),
+			ResultFile: resultFile,
+		}).Run(cfg())
+		require.NoError(t, err)
+		assert.Empty(t, stdout.String(), "wrapper must print the result")
+
+		got, ok, err := readResult(resultFile)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, result{
+			Status:  statusSelected,
+			Text:    "deadbeef",
+			Regexes: []string{"gitsha"},
+		}, got)
+	})
+}
+
+func TestApp_Run_regexOnlyUndefined(t *testing.T) {
+	t.Parallel()
+
+	err := (&app{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxtest.NewMockDriver(gomock.NewController(t)),
+	}).Run(&config{RegexOnly: "url"})
+	assert.ErrorContains(t, err, `regex "url" is not defined`)
+}
+
+func TestApp_Run_result(t *testing.T) {
+	t.Parallel()
+
+	newTmux := func(t *testing.T) *tmuxtest.MockDriver {
+		tmuxDriver := tmuxtest.NewMockDriver(gomock.NewController(t))
+		tmuxDriver.EXPECT().
+			DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: "42"}).
+			Return([]byte("%42\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+		tmuxDriver.EXPECT().
+			CapturePane(tmux.CapturePaneRequest{Pane: "%42", JoinLines: true}).
+			Return([]byte("foo 1234 bar 5678\n"), nil)
+		return tmuxDriver
+	}
+
+	tests := []struct {
+		desc      string
+		keys      keySequence
+		actionErr error
+
+		want    result
+		wantErr string
+	}{
+		{
+			desc: "selected",
+			keys: "a",
+			want: result{
+				Status:  statusSelected,
+				Text:    "1234",
+				Regexes: []string{"int"},
+			},
+		},
+		{
+			desc: "selected from list",
+			keys: "<C-l>a",
+			want: result{
+				Status:  statusSelected,
+				Text:    "1234",
+				Regexes: []string{"int"},
+			},
+		},
+		{
+			desc: "cancelled",
+			keys: "<Esc>",
+			want: result{Status: statusCancelled},
+		},
+		{
+			desc:      "action error",
+			keys:      "a",
+			actionErr: errors.New("great sadness"),
+			want: result{
+				Status:  statusError,
+				Error:   "great sadness",
+				Text:    "1234",
+				Regexes: []string{"int"},
+			},
+			wantErr: "great sadness",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			resultFile := filepath.Join(t.TempDir(), "result")
+			err := (&app{
+				Log:  logtest.NewLogger(t),
+				Tmux: newTmux(t),
+				NewAction: func(newActionRequest) (action, error) {
+					if tt.want.Status == statusCancelled {
+						t.Error("action must not run if nothing was selected")
+					}
+					return actionFunc(func(fastcopy.Selection) error {
+						return tt.actionErr
+					}), nil
+				},
+				ResultFile: resultFile,
+			}).Run(&config{
+				Pane:     "42",
+				Action:   "pbcopy",
+				Alphabet: "ab",
+				Regexes:  regexes{"int": `\d+`},
+				Keys:     tt.keys,
+			})
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			got, ok, err := readResult(resultFile)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+)
+
+// autoSelect picks the selection to use without showing the UI if the
+// matches contain exactly one unique candidate. If regex is non-empty, only
+// matches of the regex with that name are considered candidates.
+//
+// ok is false if there were no candidates, or if there were several.
+func autoSelect(text string, matches []fastcopy.Match, regex string) (sel fastcopy.Selection, ok bool) {
+	for _, m := range matches {
+		if len(regex) > 0 && m.Matcher != regex {
+			continue
+		}
+
+		t := m.TextIn(text)
+		if ok && t != sel.Text {
+			return fastcopy.Selection{}, false
+		}
+		sel.Text = t
+		ok = true
+	}
+	if !ok {
+		return sel, false
+	}
+	return newSelection(text, matches, sel.Text), true
+}
+
+// newSelection builds a selection for the given matched text, reporting
+// every matcher that matched it, just like the UI would have.
+func newSelection(text string, matches []fastcopy.Match, selected string) fastcopy.Selection {
+	matchers := make(map[string]struct{})
+	for _, m := range matches {
+		if m.TextIn(text) == selected {
+			matchers[m.Matcher] = struct{}{}
+		}
+	}
+
+	sel := fastcopy.Selection{Text: selected}
+	for m := range matchers {
+		sel.Matchers = append(sel.Matchers, m)
+	}
+	sort.Strings(sel.Matchers)
+	return sel
+}
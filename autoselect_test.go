@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoSelect(t *testing.T) {
+	t.Parallel()
+
+	// 0123456789012345678901234
+	// foo 1234 bar 1234 baz 567
+	text := "foo 1234 bar 1234 baz 567"
+
+	tests := []struct {
+		desc    string
+		matches []fastcopy.Match
+		regex   string
+
+		want   fastcopy.Selection
+		wantOK bool
+	}{
+		{desc: "no matches"},
+		{
+			desc: "single match",
+			matches: []fastcopy.Match{
+				{Matcher: "int", Range: fastcopy.Range{Start: 4, End: 8}},
+			},
+			want:   fastcopy.Selection{Text: "1234", Matchers: []string{"int"}},
+			wantOK: true,
+		},
+		{
+			desc: "same text repeated",
+			matches: []fastcopy.Match{
+				{Matcher: "int", Range: fastcopy.Range{Start: 4, End: 8}},
+				{Matcher: "num", Range: fastcopy.Range{Start: 13, End: 17}},
+			},
+			want:   fastcopy.Selection{Text: "1234", Matchers: []string{"int", "num"}},
+			wantOK: true,
+		},
+		{
+			desc: "multiple candidates",
+			matches: []fastcopy.Match{
+				{Matcher: "int", Range: fastcopy.Range{Start: 4, End: 8}},
+				{Matcher: "int", Range: fastcopy.Range{Start: 22, End: 25}},
+			},
+		},
+		{
+			desc: "restricted to regex",
+			matches: []fastcopy.Match{
+				{Matcher: "word", Range: fastcopy.Range{Start: 0, End: 3}},
+				{Matcher: "int", Range: fastcopy.Range{Start: 4, End: 8}},
+				{Matcher: "word", Range: fastcopy.Range{Start: 9, End: 12}},
+			},
+			regex:  "int",
+			want:   fastcopy.Selection{Text: "1234", Matchers: []string{"int"}},
+			wantOK: true,
+		},
+		{
+			desc: "regex not matched",
+			matches: []fastcopy.Match{
+				{Matcher: "word", Range: fastcopy.Range{Start: 0, End: 3}},
+			},
+			regex: "int",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := autoSelect(text, tt.matches, tt.regex)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+)
+
+// _altKey is the key name that binds an action to typing a label with Alt
+// held down.
+const _altKey = "alt"
+
+// _defaultActionName refers to @fastcopy-action where a named action is
+// expected.
+const _defaultActionName = "default"
+
+// namedActions is a map from action name to the command that the action
+// runs.
+type namedActions = mapFlag[namedActionSpec]
+
+type namedActionSpec struct{}
+
+func (namedActionSpec) FlagName() string { return "named-action" }
+
+func (namedActionSpec) FormError() string {
+	return "named actions must be in the form NAME:COMMAND"
+}
+
+func (namedActionSpec) Check(k, _ string) error {
+	switch k {
+	case "":
+		return errors.New("action must have a name")
+	case fastcopy.ShiftAction:
+		return fmt.Errorf("action name %q is reserved for the shift action", k)
+	case _defaultActionName:
+		return fmt.Errorf("action name %q is reserved for the default action", k)
+	}
+	return nil
+}
+
+// actionBindings is a map from action name to the key that requests the
+// action. The key is either "alt", to type the label with Alt held down, or
+// a single character that isn't uppercase to press before the label.
+type actionBindings = mapFlag[actionBindingSpec]
+
+type actionBindingSpec struct{}
+
+func (actionBindingSpec) FlagName() string { return "bind" }
+
+func (actionBindingSpec) FormError() string {
+	return "bindings must be in the form NAME:KEY"
+}
+
+func (actionBindingSpec) Check(k, v string) error {
+	if len(k) == 0 {
+		return errors.New("binding must have an action name")
+	}
+	if v == _altKey {
+		return nil
+	}
+
+	// Keys are matched against one rune at a time,
+	// with uppercase letters turned into Shift.
+	if utf8.RuneCountInString(v) != 1 {
+		return fmt.Errorf("key %q must be %q or a single character", v, _altKey)
+	}
+	if r, _ := utf8.DecodeRuneInString(v); unicode.IsUpper(r) {
+		return fmt.Errorf("key %q must not be uppercase", v)
+	}
+	return nil
+}
+
+// actionKeys reports the keys bound to actions in this configuration: the
+// action requested with Alt, if any, and the actions requested with leader
+// keys.
+//
+// It fails if an action is bound to a key that can't be told apart from
+// another one, or if the action doesn't exist.
+func (c *config) actionKeys() (alt string, leaders map[string]string, err error) {
+	names := make([]string, 0, len(c.Bindings))
+	for name := range c.Bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bound := make(map[string]string) // key -> action
+	for _, name := range names {
+		key := c.Bindings[name]
+		if name != fastcopy.ShiftAction && len(c.NamedActions[name]) == 0 {
+			return "", nil, fmt.Errorf("bind %v: action %q is not defined", name, name)
+		}
+		if other, ok := bound[key]; ok {
+			return "", nil, fmt.Errorf("bind %v: %q is already bound to %q", name, key, other)
+		}
+		bound[key] = name
+
+		if key == _altKey {
+			alt = name
+			continue
+		}
+
+		if strings.Contains(string(c.Alphabet), key) {
+			return "", nil, fmt.Errorf("bind %v: %q is part of the alphabet", name, key)
+		}
+		if leaders == nil {
+			leaders = make(map[string]string)
+		}
+		leaders[key] = name
+	}
+	return alt, leaders, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedActions(t *testing.T) {
+	t.Parallel()
+
+	var m namedActions
+	require.NoError(t, m.Set("open:xdg-open {}"))
+	require.NoError(t, m.Set("url:open https://example.com"))
+	assert.Equal(t, namedActions{
+		"open": "xdg-open {}",
+		"url":  "open https://example.com",
+	}, m)
+
+	assert.ErrorContains(t, m.Set("open"), "must be in the form NAME:COMMAND")
+	assert.ErrorContains(t, m.Set(":open"), "action must have a name")
+	assert.ErrorContains(t, m.Set("shift:open"), `action name "shift" is reserved`)
+	assert.ErrorContains(t, m.Set("default:open"), `action name "default" is reserved`)
+}
+
+func TestActionBindings(t *testing.T) {
+	t.Parallel()
+
+	var m actionBindings
+	require.NoError(t, m.Set("open:alt"))
+	require.NoError(t, m.Set("edit::"))
+	require.NoError(t, m.Set("note:é"))
+	assert.Equal(t, actionBindings{
+		"open": "alt",
+		"edit": ":",
+		"note": "é",
+	}, m)
+
+	assert.ErrorContains(t, m.Set("open"), "must be in the form NAME:KEY")
+	assert.ErrorContains(t, m.Set(":,"), "binding must have an action name")
+	assert.ErrorContains(t, m.Set("open:ctrl"), `key "ctrl" must be "alt" or a single character`)
+	assert.ErrorContains(t, m.Set("open:"), `key "" must be "alt" or a single character`)
+	assert.ErrorContains(t, m.Set("open:e\u0301"), `must be "alt" or a single character`)
+
+	// Uppercase letters are typed with Shift so they'd never match.
+	assert.ErrorContains(t, m.Set("edit:E"), `key "E" must not be uppercase`)
+	assert.ErrorContains(t, m.Set("edit:É"), `key "É" must not be uppercase`)
+}
+
+func TestConfigActionKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give config
+
+		wantAlt     string
+		wantLeaders map[string]string
+		wantErr     string
+	}{
+		{desc: "empty"},
+		{
+			desc: "alt and leaders",
+			give: config{
+				Alphabet:     "asdf",
+				NamedActions: namedActions{"open": "xdg-open {}", "edit": "vim {}"},
+				Bindings: actionBindings{
+					"open":  "alt",
+					"edit":  ",",
+					"shift": ".",
+				},
+			},
+			wantAlt:     "open",
+			wantLeaders: map[string]string{",": "edit", ".": "shift"},
+		},
+		{
+			desc: "undefined action",
+			give: config{
+				Alphabet: "asdf",
+				Bindings: actionBindings{"open": "alt"},
+			},
+			wantErr: `bind open: action "open" is not defined`,
+		},
+		{
+			desc: "leader in alphabet",
+			give: config{
+				Alphabet:     "asdf",
+				NamedActions: namedActions{"open": "xdg-open {}"},
+				Bindings:     actionBindings{"open": "s"},
+			},
+			wantErr: `bind open: "s" is part of the alphabet`,
+		},
+		{
+			desc: "same key",
+			give: config{
+				Alphabet:     "asdf",
+				NamedActions: namedActions{"open": "xdg-open {}", "edit": "vim {}"},
+				Bindings:     actionBindings{"open": ",", "edit": ","},
+			},
+			wantErr: `bind open: "," is already bound to "edit"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			alt, leaders, err := tt.give.actionKeys()
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlt, alt)
+			assert.Equal(t, tt.wantLeaders, leaders)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+)
+
+// captureBuffers loads the contents of all tmux paste buffers and finds
+// matches in them. The buffers are shown in place of the target pane, one
+// after the other, each under a header with its name.
+//
+// The most recent buffer is placed last so that it's at the bottom of the
+// overlay, where it's visible without scrolling.
+func captureBuffers(driver tmux.Driver, m matcher, targetPane *tmux.PaneInfo) (*capture, error) {
+	buffers, err := tmux.InspectBuffers(driver)
+	if err != nil {
+		return nil, fmt.Errorf("list buffers: %v", err)
+	}
+	if len(buffers) == 0 {
+		return nil, errors.New("there are no paste buffers")
+	}
+
+	c := capture{
+		Width:  targetPane.Width,
+		Height: targetPane.Height,
+		Pane:   targetPane,
+	}
+
+	var sb strings.Builder
+	for i := len(buffers) - 1; i >= 0; i-- {
+		name := buffers[i].Name
+		out, err := driver.ShowBuffer(tmux.ShowBufferRequest{Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("show buffer %q: %v", name, err)
+		}
+
+		text := cleanText(string(out))
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+
+		sb.WriteString("── " + name + " ──\n")
+		offset := sb.Len()
+		sb.WriteString(text)
+
+		// Match each buffer separately so that matches don't run
+		// across buffers.
+		for _, match := range m.Match(text) {
+			match.Range.Start += offset
+			match.Range.End += offset
+			c.Matches = append(c.Matches, match)
+		}
+	}
+	c.Text = sb.String()
+	return &c, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCaptureBuffers(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListBuffers(gomock.Any()).
+		Return([]byte("buffer1\t9\nbuffer0\t10\n"), nil)
+	tmuxDriver.EXPECT().
+		ShowBuffer(tmux.ShowBufferRequest{Name: "buffer0"}).
+		Return([]byte("1234\r\nab\tc\n"), nil)
+	tmuxDriver.EXPECT().
+		ShowBuffer(tmux.ShowBufferRequest{Name: "buffer1"}).
+		// No trailing newline.
+		Return([]byte("c 5678"), nil)
+
+	m, err := newMatcher(regexes{
+		"int": `\d{4}`,
+		// Matches never run across buffers.
+		"eol": `c\n`,
+	})
+	require.NoError(t, err)
+
+	targetPane := &tmux.PaneInfo{ID: "%1", Width: 80, Height: 24}
+	got, err := captureBuffers(tmuxDriver, m, targetPane)
+	require.NoError(t, err)
+
+	assert.Equal(t, "── buffer0 ──\n"+
+		"1234\n"+
+		"ab      c\n"+
+		"── buffer1 ──\n"+
+		"c 5678\n", got.Text)
+	assert.Equal(t, 80, got.Width)
+	assert.Equal(t, 24, got.Height)
+	assert.Same(t, targetPane, got.Pane)
+
+	var matched []string
+	for _, m := range got.Matches {
+		matched = append(matched, m.Matcher+":"+got.Text[m.Range.Start:m.Range.End])
+	}
+	assert.ElementsMatch(t, []string{
+		"int:1234",
+		"eol:c\n",
+		"int:5678",
+	}, matched)
+	assert.Same(t, targetPane, got.PaneOf("5678"))
+}
+
+func TestCaptureBuffers_errors(t *testing.T) {
+	t.Parallel()
+
+	m, err := newMatcher(regexes{"int": `\d{4}`})
+	require.NoError(t, err)
+	targetPane := &tmux.PaneInfo{ID: "%1"}
+
+	t.Run("no buffers", func(t *testing.T) {
+		t.Parallel()
+
+		mockCtrl := gomock.NewController(t)
+		tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+		tmuxDriver.EXPECT().ListBuffers(gomock.Any()).Return(nil, nil)
+
+		_, err := captureBuffers(tmuxDriver, m, targetPane)
+		assert.ErrorContains(t, err, "there are no paste buffers")
+	})
+
+	t.Run("show buffer", func(t *testing.T) {
+		t.Parallel()
+
+		mockCtrl := gomock.NewController(t)
+		tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+		tmuxDriver.EXPECT().
+			ListBuffers(gomock.Any()).
+			Return([]byte("buffer0\t3\n"), nil)
+		tmuxDriver.EXPECT().
+			ShowBuffer(gomock.Any()).
+			Return(nil, errors.New("great sadness"))
+
+		_, err := captureBuffers(tmuxDriver, m, targetPane)
+		assert.ErrorContains(t, err, `show buffer "buffer0": great sadness`)
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"go.uber.org/multierr"
+)
+
+// Actions with this prefix are implemented by tmux-fastcopy itself instead
+// of running a command.
+const _builtinPrefix = "builtin:"
+
+// _defaultAction copies the selection into a tmux buffer.
+const _defaultAction = _builtinPrefix + "buffer"
+
+// _ttyPath is the terminal that builtin:osc52 writes to outside tmux.
+const _ttyPath = "/dev/tty"
+
+// newBuiltin builds the builtin action with the given name, not including
+// the "builtin:" prefix.
+func (f *actionFactory) newBuiltin(name string, req newActionRequest) (action, error) {
+	if name == "osc52" {
+		return &osc52Action{
+			Tmux:   f.Tmux,
+			PaneID: req.TargetPaneID,
+		}, nil
+	}
+
+	if f.Tmux == nil {
+		return nil, fmt.Errorf("%v%v can only be used inside tmux", _builtinPrefix, name)
+	}
+
+	switch {
+	case name == "buffer":
+		return &bufferAction{
+			Tmux:      f.Tmux,
+			Clipboard: f.Version.SupportsLoadBufferClipboard(),
+		}, nil
+
+	case name == "append-buffer":
+		return &bufferAction{
+			Tmux:   f.Tmux,
+			Append: true,
+		}, nil
+
+	case name == "paste":
+		return &pasteAction{
+			Tmux:   f.Tmux,
+			PaneID: req.TargetPaneID,
+		}, nil
+	}
+
+	if bufName, ok := strings.CutPrefix(name, "buffer-named:"); ok {
+		if len(bufName) == 0 {
+			return nil, errors.New("buffer name must not be empty")
+		}
+		return &bufferAction{
+			Tmux:      f.Tmux,
+			Name:      bufName,
+			Clipboard: f.Version.SupportsLoadBufferClipboard(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown builtin action %q", name)
+}
+
+// bufferAction copies the selection into a tmux buffer.
+type bufferAction struct {
+	Tmux tmux.Driver
+
+	// Name of the buffer. If empty, a new buffer is added, or with Append,
+	// the most recent buffer is changed.
+	Name string
+
+	Append    bool
+	Clipboard bool
+}
+
+func (a *bufferAction) Run(sel fastcopy.Selection) error {
+	name := a.Name
+	if a.Append && len(name) == 0 {
+		// set-buffer -a adds a new buffer without a name
+		// instead of appending to the most recent one.
+		buffers, err := tmux.InspectBuffers(a.Tmux)
+		if err != nil {
+			return fmt.Errorf("list buffers: %v", err)
+		}
+		if len(buffers) > 0 {
+			name = buffers[0].Name
+		}
+	}
+
+	return a.Tmux.SetBuffer(tmux.SetBufferRequest{
+		Name:      name,
+		Data:      sel.Text,
+		Append:    a.Append,
+		Clipboard: a.Clipboard,
+	})
+}
+
+// pasteAction types the selection into a tmux pane.
+type pasteAction struct {
+	Tmux   tmux.Driver
+	PaneID string
+}
+
+func (a *pasteAction) Run(sel fastcopy.Selection) error {
+	return a.Tmux.SendKeys(tmux.SendKeysRequest{
+		Pane:    a.PaneID,
+		Keys:    []string{sel.Text},
+		Literal: true,
+	})
+}
+
+// osc52Action copies the selection to the system clipboard by writing an
+// OSC 52 escape sequence to the terminal.
+//
+// Inside tmux, this writes to the terminal of the most recently active client
+// attached to the pane's session, bypassing tmux.
+type osc52Action struct {
+	Tmux   tmux.Driver // nil outside tmux
+	PaneID string
+}
+
+func (a *osc52Action) Run(sel fastcopy.Selection) (err error) {
+	path := _ttyPath
+	if a.Tmux != nil {
+		path, err = a.clientTTY()
+		if err != nil {
+			return err
+		}
+	}
+
+	tty, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer multierr.AppendInvoke(&err, multierr.Close(tty))
+
+	_, err = io.WriteString(tty, osc52Sequence(sel.Text))
+	return err
+}
+
+// clientTTY finds the terminal of the client attached to the pane's session.
+//
+// This lists the clients instead of asking for #{client_tty} because in
+// control mode, that reports the control mode client, which has no terminal.
+func (a *osc52Action) clientTTY() (string, error) {
+	clients, err := tmux.InspectClients(a.Tmux, a.PaneID)
+	if err != nil {
+		return "", fmt.Errorf("find client terminal: %v", err)
+	}
+
+	for _, c := range clients {
+		if !c.ControlMode && len(c.TTY) > 0 {
+			return c.TTY, nil
+		}
+	}
+	return "", errors.New("no tmux client is attached")
+}
+
+// osc52Sequence builds the escape sequence that asks the terminal to put
+// the given text in the clipboard.
+func osc52Sequence(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewBuiltinAction(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+
+	tests := []struct {
+		desc    string
+		give    string
+		version tmux.Version
+		want    action
+		wantErr string
+	}{
+		{
+			desc: "buffer",
+			give: "builtin:buffer",
+			want: &bufferAction{Tmux: tmuxDriver},
+		},
+		{
+			desc:    "buffer/clipboard",
+			give:    "builtin:buffer",
+			version: tmux.Version{Major: 3, Minor: 2},
+			want:    &bufferAction{Tmux: tmuxDriver, Clipboard: true},
+		},
+		{
+			desc: "buffer named",
+			give: "builtin:buffer-named:urls",
+			want: &bufferAction{Tmux: tmuxDriver, Name: "urls"},
+		},
+		{
+			desc:    "buffer named/empty",
+			give:    "builtin:buffer-named:",
+			wantErr: "buffer name must not be empty",
+		},
+		{
+			desc: "append buffer",
+			give: "builtin:append-buffer",
+			want: &bufferAction{Tmux: tmuxDriver, Append: true},
+		},
+		{
+			desc: "paste",
+			give: "builtin:paste",
+			want: &pasteAction{Tmux: tmuxDriver, PaneID: "%42"},
+		},
+		{
+			desc: "osc52",
+			give: "builtin:osc52",
+			want: &osc52Action{Tmux: tmuxDriver, PaneID: "%42"},
+		},
+		{
+			desc:    "unknown",
+			give:    "builtin:foo",
+			wantErr: `unknown builtin action "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := (&actionFactory{
+				Tmux:    tmuxDriver,
+				Version: tt.version,
+			}).New(newActionRequest{
+				Action:       tt.give,
+				TargetPaneID: "%42",
+			})
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewBuiltinAction_noTmux(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&actionFactory{}).New(newActionRequest{Action: "builtin:buffer"})
+	assert.ErrorContains(t, err, "builtin:buffer can only be used inside tmux")
+
+	// OSC 52 writes to the terminal directly outside tmux.
+	got, err := (&actionFactory{}).New(newActionRequest{Action: "builtin:osc52"})
+	require.NoError(t, err)
+	assert.Equal(t, &osc52Action{}, got)
+}
+
+func TestBufferAction(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		SetBuffer(tmux.SetBufferRequest{
+			Name:      "cmds",
+			Data:      "ls -l;",
+			Clipboard: true,
+		}).
+		Return(nil)
+
+	err := (&bufferAction{
+		Tmux:      tmuxDriver,
+		Name:      "cmds",
+		Clipboard: true,
+	}).Run(fastcopy.Selection{Text: "ls -l;"})
+	assert.NoError(t, err)
+}
+
+func TestBufferAction_append(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListBuffers(gomock.Any()).
+		Return([]byte("buffer2\t5\nbuffer1\t3\n"), nil)
+	tmuxDriver.EXPECT().
+		SetBuffer(tmux.SetBufferRequest{
+			Name:   "buffer2",
+			Data:   "foo",
+			Append: true,
+		}).
+		Return(nil)
+
+	err := (&bufferAction{
+		Tmux:   tmuxDriver,
+		Append: true,
+	}).Run(fastcopy.Selection{Text: "foo"})
+	assert.NoError(t, err)
+}
+
+func TestBufferAction_appendNoBuffers(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListBuffers(gomock.Any()).
+		Return(nil, nil)
+	tmuxDriver.EXPECT().
+		SetBuffer(tmux.SetBufferRequest{Data: "foo", Append: true}).
+		Return(nil)
+
+	err := (&bufferAction{
+		Tmux:   tmuxDriver,
+		Append: true,
+	}).Run(fastcopy.Selection{Text: "foo"})
+	assert.NoError(t, err)
+}
+
+func TestPasteAction(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		SendKeys(tmux.SendKeysRequest{
+			Pane:    "%42",
+			Keys:    []string{"git show abc123;"},
+			Literal: true,
+		}).
+		Return(nil)
+
+	err := (&pasteAction{
+		Tmux:   tmuxDriver,
+		PaneID: "%42",
+	}).Run(fastcopy.Selection{Text: "git show abc123;"})
+	assert.NoError(t, err)
+}
+
+func TestOSC52Action(t *testing.T) {
+	t.Parallel()
+
+	tty := filepath.Join(t.TempDir(), "tty")
+	require.NoError(t, os.WriteFile(tty, nil, 0o600))
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListClients(gomock.Cond(func(req tmux.ListClientsRequest) bool {
+			return req.Session == "%42"
+		})).
+		Return([]byte(tty+"\t0\t100\n"), nil)
+
+	err := (&osc52Action{
+		Tmux:   tmuxDriver,
+		PaneID: "%42",
+	}).Run(fastcopy.Selection{Text: "hello"})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(tty)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\a", string(got))
+}
+
+func TestOSC52Action_controlMode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	recent := filepath.Join(dir, "recent")
+	stale := filepath.Join(dir, "stale")
+	require.NoError(t, os.WriteFile(recent, nil, 0o600))
+	require.NoError(t, os.WriteFile(stale, nil, 0o600))
+
+	// With -control-mode, our own control client is attached to the
+	// session and was active last. It has no terminal, so the most
+	// recently active one of the other clients must be used.
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListClients(gomock.Any()).
+		Return([]byte(joinLines(
+			stale+"\t0\t100",
+			"\t1\t300",
+			recent+"\t0\t200",
+		)), nil)
+
+	err := (&osc52Action{
+		Tmux:   tmuxDriver,
+		PaneID: "%42",
+	}).Run(fastcopy.Selection{Text: "hello"})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(recent)
+	require.NoError(t, err)
+	assert.Equal(t, "\x1b]52;c;aGVsbG8=\a", string(got))
+
+	got, err = os.ReadFile(stale)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestOSC52Action_noClient(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListClients(gomock.Any()).
+		Return([]byte("\t1\t100\n"), nil)
+
+	err := (&osc52Action{Tmux: tmuxDriver}).Run(fastcopy.Selection{Text: "hello"})
+	assert.ErrorContains(t, err, "no tmux client is attached")
+}
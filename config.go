@@ -4,10 +4,9 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"sort"
-	"strings"
+	"strconv"
 
-	"github.com/abhinav/tmux-fastcopy/internal/must"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxopt"
 )
 
@@ -24,71 +23,101 @@ var _defaultRegexes = map[string]string{
 
 // regexes is a map from regex name to body. If body is empty, this regex
 // should be skipped.
-type regexes map[string]string
+type regexes = mapFlag[regexSpec]
 
-func (m *regexes) Put(k, v string) error {
+type regexSpec struct{}
+
+func (regexSpec) FlagName() string { return "regex" }
+
+func (regexSpec) FormError() string {
+	return "regex flags must be in the form NAME:REGEX"
+}
+
+func (regexSpec) Check(k, _ string) error {
 	if len(k) == 0 {
 		return errors.New("regex must have a name")
 	}
-
-	if *m == nil {
-		*m = make(map[string]string)
-	}
-	(*m)[k] = v
 	return nil
 }
 
-func (m regexes) Flags() (args []string) {
-	names := make([]string, 0, len(m))
-	for name := range m {
-		names = append(names, name)
-	}
-	sort.Strings(names)
+// regexActions is a map from regex name to the command that handles text
+// matched by that regex.
+type regexActions = mapFlag[regexActionSpec]
 
-	for _, name := range names {
-		args = append(args, "-regex", name+":"+m[name])
-	}
+type regexActionSpec struct{}
 
-	return args
-}
+func (regexActionSpec) FlagName() string { return "regex-action" }
 
-func (m regexes) String() string {
-	return fmt.Sprint(m.Flags())
+func (regexActionSpec) FormError() string {
+	return "regex actions must be in the form NAME:COMMAND"
 }
 
-func (m *regexes) Set(v string) error {
-	idx := strings.IndexByte(v, ':')
-	if idx < 0 {
-		return errors.New("regex flags must be in the form NAME:REGEX")
+func (regexActionSpec) Check(k, _ string) error {
+	if len(k) == 0 {
+		return errors.New("regex action must have a regex name")
 	}
+	return nil
+}
+
+// regexShiftActions is a map from regex name to the command that handles
+// text matched by that regex when it's selected with Shift.
+type regexShiftActions = mapFlag[regexShiftActionSpec]
 
-	return m.Put(v[:idx], v[idx+1:])
+type regexShiftActionSpec struct{}
+
+func (regexShiftActionSpec) FlagName() string { return "regex-shift-action" }
+
+func (regexShiftActionSpec) FormError() string {
+	return "regex shift actions must be in the form NAME:COMMAND"
 }
 
-func (m *regexes) FillFrom(o regexes) {
-	for k, v := range o {
-		if _, ok := (*m)[k]; !ok {
-			err := m.Put(k, v)
-			must.NotErrorf(err, "unexpected invalid key %q", k)
-		}
+func (regexShiftActionSpec) Check(k, _ string) error {
+	if len(k) == 0 {
+		return errors.New("regex shift action must have a regex name")
 	}
+	return nil
 }
 
 type config struct {
-	Pane        string
-	Action      string
-	ShiftAction string
-	Alphabet    alphabet
-	Verbose     bool
-	Regexes     regexes
-	Tmux        string
-	LogFile     string
+	Pane         string
+	Action       string
+	ShiftAction  string
+	NamedActions namedActions
+	Bindings     actionBindings
+
+	RegexActions      regexActions
+	RegexShiftActions regexShiftActions
+	RegexMenus        regexMenus
+
+	Alphabet   alphabet
+	Verbose    bool
+	Regexes    regexes
+	Tmux       string
+	SocketName string
+	SocketPath string
+	LogFile    string
+	LabelCache labelCacheScope
+
+	LabelStrategy labelStrategy
+
+	SelectionHistory bool
+	AutoSelect       bool
+	AutoSelectRegex  string
+	Select           selectSpec
+	Keys             keySequence
+	Print            bool
+	PrintRegexName   bool
+	RegexOnly        string
+	Popup            bool
+	ControlMode      bool
+	HistoryLines     int
+	Scope            captureScope
 }
 
 // Generates a new default configuration.
-func defaultConfig(cfg *config) *config {
+func defaultConfig() *config {
 	return &config{
-		Action:   fmt.Sprintf("%v load-buffer -", cfg.Tmux),
+		Action:   _defaultAction,
 		Alphabet: _defaultAlphabet,
 		Regexes:  _defaultRegexes,
 	}
@@ -99,18 +128,78 @@ func (c *config) RegisterFlags(flag *flag.FlagSet) {
 	flag.StringVar(&c.Pane, "pane", "", "")
 	flag.StringVar(&c.Action, "action", "", "")
 	flag.StringVar(&c.ShiftAction, "shift-action", "", "")
+	flag.Var(&c.NamedActions, "named-action", "")
+	flag.Var(&c.Bindings, "bind", "")
+	flag.Var(&c.RegexActions, "regex-action", "")
+	flag.Var(&c.RegexShiftActions, "regex-shift-action", "")
+	flag.Var(&c.RegexMenus, "regex-menu", "")
 	flag.Var(&c.Alphabet, "alphabet", "")
 	flag.Var(&c.Regexes, "regex", "")
 	flag.BoolVar(&c.Verbose, "verbose", false, "")
 	flag.StringVar(&c.LogFile, "log", "", "")
 	flag.StringVar(&c.Tmux, "tmux", "tmux", "")
+	flag.StringVar(&c.SocketName, "socket-name", "", "")
+	flag.StringVar(&c.SocketPath, "socket-path", "", "")
+	flag.Var(&c.LabelCache, "label-cache", "")
+	flag.Var(&c.LabelStrategy, "label-strategy", "")
+	flag.BoolVar(&c.SelectionHistory, "selection-history", false, "")
+	flag.BoolVar(&c.AutoSelect, "auto-select", false, "")
+	flag.StringVar(&c.AutoSelectRegex, "auto-select-regex", "", "")
+	flag.Var(&c.Select, "select", "")
+	flag.Var(&c.Keys, "keys", "")
+	flag.BoolVar(&c.Print, "print", false, "")
+	flag.BoolVar(&c.PrintRegexName, "print-regex-name", false, "")
+	flag.StringVar(&c.RegexOnly, "regex-only", "", "")
+	flag.BoolVar(&c.Popup, "popup", false, "")
+	flag.BoolVar(&c.ControlMode, "control-mode", false, "")
+	flag.IntVar(&c.HistoryLines, "history-lines", 0, "")
+	flag.Var(&c.Scope, "scope", "")
 }
 
 func (c *config) RegisterOptions(load *tmuxopt.Loader) {
 	load.StringVar(&c.Action, "@fastcopy-action")
 	load.StringVar(&c.ShiftAction, "@fastcopy-shift-action")
+	load.MapVar(&c.NamedActions, "@fastcopy-action-")
+	load.MapVar(&c.Bindings, "@fastcopy-bind-")
+	load.MapVar(&c.RegexActions, "@fastcopy-regex-action-")
+	load.MapVar(&c.RegexShiftActions, "@fastcopy-regex-shift-action-")
+	load.MapVar(&c.RegexMenus, "@fastcopy-regex-menu-")
 	load.Var(&c.Alphabet, "@fastcopy-alphabet")
 	load.MapVar(&c.Regexes, "@fastcopy-regex-")
+	load.Var(&c.LabelCache, "@fastcopy-label-cache")
+	load.Var(&c.LabelStrategy, "@fastcopy-label-strategy")
+	load.BoolVar(&c.SelectionHistory, "@fastcopy-selection-history")
+	load.BoolVar(&c.AutoSelect, "@fastcopy-auto-select")
+	load.StringVar(&c.AutoSelectRegex, "@fastcopy-auto-select-regex")
+	load.BoolVar(&c.Popup, "@fastcopy-popup")
+	load.IntVar(&c.HistoryLines, "@fastcopy-history-lines")
+	load.Var(&c.Scope, "@fastcopy-scope")
+}
+
+// newMatcher builds a matcher for the regexes that this configuration
+// searches for.
+func (c *config) newMatcher() (matcher, error) {
+	rs := c.Regexes
+	if name := c.RegexOnly; len(name) > 0 {
+		if len(rs[name]) == 0 {
+			return nil, fmt.Errorf("regex-only: regex %q is not defined", name)
+		}
+		rs = regexes{name: rs[name]}
+	}
+	return newMatcher(rs)
+}
+
+// loadOptions fills values in this config that aren't already set with
+// values from the tmux options.
+func (c *config) loadOptions(driver tmux.Driver) error {
+	loader := tmuxopt.Loader{Tmux: driver}
+	var tmuxCfg config
+	tmuxCfg.RegisterOptions(&loader)
+	if err := loader.Load(tmux.ShowOptionsRequest{Global: true}); err != nil {
+		return fmt.Errorf("load options: %v", err)
+	}
+	c.FillFrom(&tmuxCfg)
+	return nil
 }
 
 // FillFrom updates this config object, filling empty values with values from
@@ -134,8 +223,38 @@ func (c *config) FillFrom(o *config) {
 	if len(c.Tmux) == 0 {
 		c.Tmux = o.Tmux
 	}
+	if len(c.SocketName) == 0 {
+		c.SocketName = o.SocketName
+	}
+	if len(c.SocketPath) == 0 {
+		c.SocketPath = o.SocketPath
+	}
+	if len(c.LabelCache) == 0 {
+		c.LabelCache = o.LabelCache
+	}
+	if len(c.LabelStrategy) == 0 {
+		c.LabelStrategy = o.LabelStrategy
+	}
+	if len(c.AutoSelectRegex) == 0 {
+		c.AutoSelectRegex = o.AutoSelectRegex
+	}
+	if c.HistoryLines == 0 {
+		c.HistoryLines = o.HistoryLines
+	}
+	if len(c.Scope) == 0 {
+		c.Scope = o.Scope
+	}
 	c.Regexes.FillFrom(o.Regexes)
+	c.NamedActions.FillFrom(o.NamedActions)
+	c.Bindings.FillFrom(o.Bindings)
+	c.RegexActions.FillFrom(o.RegexActions)
+	c.RegexShiftActions.FillFrom(o.RegexShiftActions)
+	c.RegexMenus.FillFrom(o.RegexMenus)
 	c.Verbose = c.Verbose || o.Verbose
+	c.SelectionHistory = c.SelectionHistory || o.SelectionHistory
+	c.AutoSelect = c.AutoSelect || o.AutoSelect
+	c.Popup = c.Popup || o.Popup
+	c.ControlMode = c.ControlMode || o.ControlMode
 }
 
 // Flags rebuilds a list of arguments from which this configuration may be
@@ -151,6 +270,11 @@ func (c *config) Flags() []string {
 	if len(c.ShiftAction) > 0 {
 		args = append(args, "-shift-action", c.ShiftAction)
 	}
+	args = append(args, c.NamedActions.Flags()...)
+	args = append(args, c.Bindings.Flags()...)
+	args = append(args, c.RegexActions.Flags()...)
+	args = append(args, c.RegexShiftActions.Flags()...)
+	args = append(args, c.RegexMenus.Flags()...)
 	if len(c.Alphabet) > 0 {
 		args = append(args, "-alphabet", c.Alphabet.String())
 	}
@@ -164,5 +288,53 @@ func (c *config) Flags() []string {
 	if len(c.Tmux) > 0 {
 		args = append(args, "-tmux", c.Tmux)
 	}
+	if len(c.SocketName) > 0 {
+		args = append(args, "-socket-name", c.SocketName)
+	}
+	if len(c.SocketPath) > 0 {
+		args = append(args, "-socket-path", c.SocketPath)
+	}
+	if len(c.LabelCache) > 0 {
+		args = append(args, "-label-cache", c.LabelCache.String())
+	}
+	if len(c.LabelStrategy) > 0 {
+		args = append(args, "-label-strategy", c.LabelStrategy.String())
+	}
+	if c.SelectionHistory {
+		args = append(args, "-selection-history")
+	}
+	if c.AutoSelect {
+		args = append(args, "-auto-select")
+	}
+	if len(c.AutoSelectRegex) > 0 {
+		args = append(args, "-auto-select-regex", c.AutoSelectRegex)
+	}
+	if c.Select.IsSet() {
+		args = append(args, "-select", c.Select.String())
+	}
+	if c.Keys.IsSet() {
+		args = append(args, "-keys", c.Keys.String())
+	}
+	if c.Print {
+		args = append(args, "-print")
+	}
+	if c.PrintRegexName {
+		args = append(args, "-print-regex-name")
+	}
+	if len(c.RegexOnly) > 0 {
+		args = append(args, "-regex-only", c.RegexOnly)
+	}
+	if c.Popup {
+		args = append(args, "-popup")
+	}
+	if c.ControlMode {
+		args = append(args, "-control-mode")
+	}
+	if c.HistoryLines != 0 {
+		args = append(args, "-history-lines", strconv.Itoa(c.HistoryLines))
+	}
+	if len(c.Scope) > 0 {
+		args = append(args, "-scope", c.Scope.String())
+	}
 	return args
 }
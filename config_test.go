@@ -4,7 +4,9 @@ import (
 	"flag"
 	"strings"
 	"testing"
+	"unicode"
 
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxopt"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
@@ -153,9 +155,9 @@ func TestConfigDefaults(t *testing.T) {
 			"prompt": "^% (.+)$",
 		},
 	}
-	cfg.FillFrom(defaultConfig(&cfg))
+	cfg.FillFrom(defaultConfig())
 
-	assert.Equal(t, "tmux load-buffer -", cfg.Action)
+	assert.Equal(t, "builtin:buffer", cfg.Action)
 	assert.Empty(t, cfg.ShiftAction)
 	assert.Equal(t, _defaultAlphabet, cfg.Alphabet)
 
@@ -198,11 +200,90 @@ func TestConfigFlags(t *testing.T) {
 			give: []string{"-shift-action", "open"},
 			want: config{ShiftAction: "open", Tmux: "tmux"},
 		},
+		{
+			desc: "named actions",
+			give: []string{
+				"-named-action", "open:xdg-open {}",
+				"-named-action", "edit:vim {}",
+				"-bind", "open:alt",
+				"-bind", "edit:,",
+			},
+			want: config{
+				NamedActions: namedActions{
+					"open": "xdg-open {}",
+					"edit": "vim {}",
+				},
+				Bindings: actionBindings{
+					"open": "alt",
+					"edit": ",",
+				},
+				Tmux: "tmux",
+			},
+		},
+		{
+			desc: "regex actions",
+			give: []string{
+				"-regex-action", "url:xdg-open {}",
+				"-regex-action", "gitsha:git show {}",
+				"-regex-shift-action", "url:curl {}",
+			},
+			want: config{
+				RegexActions: regexActions{
+					"url":    "xdg-open {}",
+					"gitsha": "git show {}",
+				},
+				RegexShiftActions: regexShiftActions{"url": "curl {}"},
+				Tmux:              "tmux",
+			},
+		},
+		{
+			desc: "regex menu",
+			give: []string{"-regex-menu", "url:open copy"},
+			want: config{
+				RegexMenus: regexMenus{"url": "open copy"},
+				Tmux:       "tmux",
+			},
+		},
+		{
+			desc:    "regex menu/no separator",
+			give:    []string{"-regex-menu", "url"},
+			wantErr: "regex menus must be in the form NAME:ACTIONS",
+		},
+		{
+			desc:    "regex menu/no name",
+			give:    []string{"-regex-menu", ":open"},
+			wantErr: "regex menu must have a regex name",
+		},
+		{
+			desc:    "regex action/no name",
+			give:    []string{"-regex-action", ":open"},
+			wantErr: "regex action must have a regex name",
+		},
+		{
+			desc:    "regex shift action/no name",
+			give:    []string{"-regex-shift-action", ":open"},
+			wantErr: "regex shift action must have a regex name",
+		},
+		{
+			desc:    "named action/reserved",
+			give:    []string{"-named-action", "shift:open"},
+			wantErr: `action name "shift" is reserved`,
+		},
+		{
+			desc:    "bind/invalid key",
+			give:    []string{"-bind", "open:ctrl"},
+			wantErr: `key "ctrl" must be "alt" or a single character`,
+		},
 		{
 			desc: "alphabet",
 			give: []string{"-alphabet", "0123456789"},
 			want: config{Alphabet: "0123456789", Tmux: "tmux"},
 		},
+		{
+			desc: "alphabet/preset",
+			give: []string{"-alphabet", "colemak-dh"},
+			want: config{Alphabet: "arstgmneio", Tmux: "tmux"},
+		},
 		{
 			desc:    "alphabet/too small",
 			give:    []string{"-alphabet", "a"},
@@ -263,6 +344,110 @@ func TestConfigFlags(t *testing.T) {
 			give: []string{"-tmux", "/usr/bin/tmux"},
 			want: config{Tmux: "/usr/bin/tmux"},
 		},
+		{
+			desc: "label cache",
+			give: []string{"-label-cache", "pane"},
+			want: config{LabelCache: labelCachePane, Tmux: "tmux"},
+		},
+		{
+			desc: "label cache/off",
+			give: []string{"-label-cache", "off"},
+			want: config{Tmux: "tmux"},
+		},
+		{
+			desc: "selection history",
+			give: []string{"-selection-history"},
+			want: config{SelectionHistory: true, Tmux: "tmux"},
+		},
+		{
+			desc: "label strategy",
+			give: []string{"-label-strategy", "alternating"},
+			want: config{LabelStrategy: labelStrategyAlternating, Tmux: "tmux"},
+		},
+		{
+			desc: "auto select",
+			give: []string{"-auto-select"},
+			want: config{AutoSelect: true, Tmux: "tmux"},
+		},
+		{
+			desc: "auto select regex",
+			give: []string{"-auto-select-regex", "url"},
+			want: config{AutoSelectRegex: "url", Tmux: "tmux"},
+		},
+		{
+			desc: "select",
+			give: []string{"-select", "gitsha:last"},
+			want: config{Select: selectSpec{Regex: "gitsha", Index: -1}, Tmux: "tmux"},
+		},
+		{
+			desc: "keys",
+			give: []string{"-keys", "<Tab>ab<Enter>"},
+			want: config{Keys: "<Tab>ab<Enter>", Tmux: "tmux"},
+		},
+		{
+			desc: "print",
+			give: []string{"-print", "-print-regex-name", "-regex-only", "gitsha"},
+			want: config{
+				Print:          true,
+				PrintRegexName: true,
+				RegexOnly:      "gitsha",
+				Tmux:           "tmux",
+			},
+		},
+		{
+			desc: "popup",
+			give: []string{"-popup"},
+			want: config{Popup: true, Tmux: "tmux"},
+		},
+		{
+			desc: "socket",
+			give: []string{"-socket-name", "pairing", "-socket-path", "/tmp/tmux.sock"},
+			want: config{
+				SocketName: "pairing",
+				SocketPath: "/tmp/tmux.sock",
+				Tmux:       "tmux",
+			},
+		},
+		{
+			desc: "control mode",
+			give: []string{"-control-mode"},
+			want: config{ControlMode: true, Tmux: "tmux"},
+		},
+		{
+			desc: "history lines",
+			give: []string{"-history-lines", "500"},
+			want: config{HistoryLines: 500, Tmux: "tmux"},
+		},
+		{
+			desc: "scope",
+			give: []string{"-scope", "window"},
+			want: config{Scope: captureScopeWindow, Tmux: "tmux"},
+		},
+		{
+			desc:    "scope/unknown",
+			give:    []string{"-scope", "client"},
+			wantErr: `unknown scope "client"`,
+		},
+		{
+			desc:    "keys/invalid",
+			give:    []string{"-keys", "<Nope>"},
+			wantErr: "unknown key <Nope>",
+		},
+		{
+			desc:    "select/invalid",
+			give:    []string{"-select", "gitsha"},
+			wantErr: "must be in the form NAME:INDEX",
+		},
+		{
+			desc:    "label strategy/unknown",
+			give:    []string{"-label-strategy", "random"},
+			wantErr: `unknown label strategy "random"`,
+		},
+		{
+			desc:    "label cache/unknown",
+			give:    []string{"-label-cache", "window"},
+			wantErr: `unknown label cache scope "window"`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,11 +511,81 @@ func TestConfigTmuxOptions(t *testing.T) {
 			give: "@fastcopy-shift-action open",
 			want: config{ShiftAction: "open"},
 		},
+		{
+			desc: "named actions",
+			give: joinLines(
+				`@fastcopy-action-open "xdg-open {}"`,
+				`@fastcopy-bind-open alt`,
+				`@fastcopy-bind-shift ,`,
+			),
+			want: config{
+				NamedActions: namedActions{"open": "xdg-open {}"},
+				Bindings: actionBindings{
+					"open":  "alt",
+					"shift": ",",
+				},
+			},
+		},
 		{
 			desc: "alphabet",
 			give: "@fastcopy-alphabet abc",
 			want: config{Alphabet: "abc"},
 		},
+		{
+			desc: "label cache",
+			give: "@fastcopy-label-cache global",
+			want: config{LabelCache: labelCacheGlobal},
+		},
+		{
+			desc: "label strategy",
+			give: "@fastcopy-label-strategy alternating",
+			want: config{LabelStrategy: labelStrategyAlternating},
+		},
+		{
+			desc: "auto select",
+			give: "@fastcopy-auto-select on",
+			want: config{AutoSelect: true},
+		},
+		{
+			desc: "auto select regex",
+			give: "@fastcopy-auto-select-regex gitsha",
+			want: config{AutoSelectRegex: "gitsha"},
+		},
+		{
+			desc: "popup",
+			give: "@fastcopy-popup on",
+			want: config{Popup: true},
+		},
+		{
+			desc: "selection history",
+			give: "@fastcopy-selection-history on",
+			want: config{SelectionHistory: true},
+		},
+		{
+			desc: "history lines",
+			give: "@fastcopy-history-lines 500",
+			want: config{HistoryLines: 500},
+		},
+		{
+			desc: "scope",
+			give: "@fastcopy-scope window",
+			want: config{Scope: captureScopeWindow},
+		},
+		{
+			desc: "regex actions",
+			give: joinLines(
+				`@fastcopy-regex-url "https?://\\S+"`,
+				`@fastcopy-regex-action-url "xdg-open {}"`,
+				`@fastcopy-regex-shift-action-url "curl {}"`,
+				`@fastcopy-regex-menu-url "open copy"`,
+			),
+			want: config{
+				Regexes:           regexes{"url": `https?://\S+`},
+				RegexActions:      regexActions{"url": "xdg-open {}"},
+				RegexShiftActions: regexShiftActions{"url": "curl {}"},
+				RegexMenus:        regexMenus{"url": "open copy"},
+			},
+		},
 		{
 			desc: "regexes",
 			give: joinLines(
@@ -396,12 +651,23 @@ func TestConfigMerge(t *testing.T) {
 					},
 				},
 				{
-					Pane:        "ignored",
-					Action:      "ignored",
-					ShiftAction: "open",
-					Alphabet:    "ignored",
-					LogFile:     "ignored.txt",
-					Tmux:        "/usr/bin/tmux",
+					Pane:              "ignored",
+					Action:            "ignored",
+					ShiftAction:       "open",
+					NamedActions:      namedActions{"open": "xdg-open {}"},
+					Bindings:          actionBindings{"open": "alt"},
+					RegexActions:      regexActions{"url": "xdg-open {}"},
+					RegexShiftActions: regexShiftActions{"url": "curl {}"},
+					RegexMenus:        regexMenus{"url": "open copy"},
+					Alphabet:          "ignored",
+					LogFile:           "ignored.txt",
+					Tmux:              "/usr/bin/tmux",
+					LabelCache:        labelCachePane,
+					LabelStrategy:     labelStrategyAlternating,
+					AutoSelect:        true,
+					AutoSelectRegex:   "url",
+					Popup:             true,
+					HistoryLines:      500,
 					Regexes: regexes{
 						"foo": "ignored",
 						"bar": "baz",
@@ -409,13 +675,24 @@ func TestConfigMerge(t *testing.T) {
 				},
 			},
 			want: config{
-				Pane:        "foo",
-				Action:      "bar",
-				ShiftAction: "open",
-				Alphabet:    "abc",
-				Verbose:     true,
-				LogFile:     "foo.txt",
-				Tmux:        "/usr/bin/tmux",
+				Pane:              "foo",
+				Action:            "bar",
+				ShiftAction:       "open",
+				NamedActions:      namedActions{"open": "xdg-open {}"},
+				Bindings:          actionBindings{"open": "alt"},
+				RegexActions:      regexActions{"url": "xdg-open {}"},
+				RegexShiftActions: regexShiftActions{"url": "curl {}"},
+				RegexMenus:        regexMenus{"url": "open copy"},
+				Alphabet:          "abc",
+				Verbose:           true,
+				LogFile:           "foo.txt",
+				Tmux:              "/usr/bin/tmux",
+				LabelCache:        labelCachePane,
+				LabelStrategy:     labelStrategyAlternating,
+				AutoSelect:        true,
+				AutoSelectRegex:   "url",
+				Popup:             true,
+				HistoryLines:      500,
 				Regexes: regexes{
 					"foo": "bar",
 					"bar": "baz",
@@ -435,20 +712,57 @@ func TestConfigMerge(t *testing.T) {
 				{Regexes: regexes{"bar": "ignored"}},
 				{LogFile: "foo.txt"},
 				{Tmux: "/usr/local/bin/tmux"},
+				{SocketName: "pairing"},
+				{SocketPath: "/tmp/tmux.sock"},
 				{ShiftAction: "open"},
+				{NamedActions: namedActions{"open": "xdg-open {}"}},
+				{NamedActions: namedActions{"open": "ignored", "edit": "vim {}"}},
+				{Bindings: actionBindings{"open": "alt"}},
+				{Bindings: actionBindings{"open": ",", "edit": "."}},
+				{RegexActions: regexActions{"url": "xdg-open {}"}},
+				{RegexActions: regexActions{"url": "ignored", "path": "vim {}"}},
+				{RegexShiftActions: regexShiftActions{"url": "curl {}"}},
+				{RegexMenus: regexMenus{"url": "open copy"}},
+				{RegexMenus: regexMenus{"url": "ignored"}},
+				{SelectionHistory: true},
+				{ControlMode: true},
+				{HistoryLines: 100},
+				{HistoryLines: 200},
+				{Scope: captureScopeWindow},
+				{Scope: captureScopePane},
 			},
 			want: config{
 				Pane:        "foo",
 				Action:      "bar",
 				ShiftAction: "open",
-				Alphabet:    "abc",
-				Verbose:     true,
+				NamedActions: namedActions{
+					"open": "xdg-open {}",
+					"edit": "vim {}",
+				},
+				Bindings: actionBindings{
+					"open": "alt",
+					"edit": ".",
+				},
+				RegexActions: regexActions{
+					"url":  "xdg-open {}",
+					"path": "vim {}",
+				},
+				RegexShiftActions: regexShiftActions{"url": "curl {}"},
+				RegexMenus:        regexMenus{"url": "open copy"},
+				Alphabet:          "abc",
+				Verbose:           true,
 				Regexes: regexes{
 					"foo": "bar",
 					"bar": "baz",
 				},
-				LogFile: "foo.txt",
-				Tmux:    "/usr/local/bin/tmux",
+				LogFile:          "foo.txt",
+				Tmux:             "/usr/local/bin/tmux",
+				SocketName:       "pairing",
+				SocketPath:       "/tmp/tmux.sock",
+				SelectionHistory: true,
+				ControlMode:      true,
+				HistoryLines:     100,
+				Scope:            captureScopeWindow,
 			},
 		},
 	}
@@ -497,6 +811,21 @@ func TestConfigFlags_rapid(t *testing.T) {
 		if len(give.Regexes) == 0 {
 			give.Regexes = nil // to make nil v non-nil map comparison easier
 		}
+		if len(give.NamedActions) == 0 {
+			give.NamedActions = nil
+		}
+		if len(give.Bindings) == 0 {
+			give.Bindings = nil
+		}
+		if len(give.RegexActions) == 0 {
+			give.RegexActions = nil
+		}
+		if len(give.RegexShiftActions) == 0 {
+			give.RegexShiftActions = nil
+		}
+		if len(give.RegexMenus) == 0 {
+			give.RegexMenus = nil
+		}
 		require.Equal(t, give, got)
 	})
 }
@@ -520,7 +849,13 @@ func TestUsageHasAllConfigFlags(t *testing.T) {
 
 func configGenerator() *rapid.Generator[config] {
 	alphabetGen := rapid.Custom(func(t *rapid.T) alphabet {
-		alpha := rapid.SliceOfNDistinct(rapid.Rune(), 2, -1, rapid.ID[rune]).Draw(t, "alphabet")
+		alpha := rapid.SliceOfNDistinct(rapid.Rune(), 2, -1, rapid.ID[rune]).
+			Filter(func(rs []rune) bool {
+				// Preset names don't round-trip.
+				_, ok := _alphabetPresets[string(rs)]
+				return !ok
+			}).
+			Draw(t, "alphabet")
 		return alphabet(alpha)
 	})
 
@@ -531,16 +866,71 @@ func configGenerator() *rapid.Generator[config] {
 		rapid.StringN(1, -1, -1),
 	)
 
+	actionNameGen := rapid.StringN(1, -1, -1).Filter(func(s string) bool {
+		return !strings.Contains(s, ":") && s != fastcopy.ShiftAction
+	})
+	namedActionGen := rapid.MapOf(actionNameGen, rapid.String())
+	bindingGen := rapid.MapOf(actionNameGen, rapid.OneOf(
+		rapid.Just(_altKey),
+		rapid.Map(
+			rapid.Rune().Filter(func(r rune) bool { return !unicode.IsUpper(r) }),
+			func(r rune) string { return string(r) },
+		),
+	))
+
+	regexActionGen := rapid.MapOf(
+		rapid.StringN(1, -1, -1).Filter(func(s string) bool {
+			return !strings.Contains(s, ":")
+		}),
+		rapid.String(),
+	)
+
 	return rapid.Custom(func(t *rapid.T) config {
 		return config{
-			Pane:        rapid.String().Draw(t, "pane"),
-			Action:      rapid.String().Draw(t, "action"),
-			ShiftAction: rapid.String().Draw(t, "shift action"),
-			Alphabet:    alphabetGen.Draw(t, "alphabet"),
-			Verbose:     rapid.Bool().Draw(t, "verbose"),
-			Regexes:     regexGen.Draw(t, "regexes"),
-			LogFile:     rapid.String().Draw(t, "logFile"),
-			Tmux:        rapid.StringN(1, -1, -1).Draw(t, "tmux"),
+			Pane:              rapid.String().Draw(t, "pane"),
+			Action:            rapid.String().Draw(t, "action"),
+			ShiftAction:       rapid.String().Draw(t, "shift action"),
+			NamedActions:      namedActionGen.Draw(t, "namedActions"),
+			Bindings:          bindingGen.Draw(t, "bindings"),
+			RegexActions:      regexActionGen.Draw(t, "regexActions"),
+			RegexShiftActions: regexActionGen.Draw(t, "regexShiftActions"),
+			RegexMenus:        regexActionGen.Draw(t, "regexMenus"),
+			Alphabet:          alphabetGen.Draw(t, "alphabet"),
+			Verbose:           rapid.Bool().Draw(t, "verbose"),
+			Regexes:           regexGen.Draw(t, "regexes"),
+			LogFile:           rapid.String().Draw(t, "logFile"),
+			Tmux:              rapid.StringN(1, -1, -1).Draw(t, "tmux"),
+			SocketName:        rapid.String().Draw(t, "socketName"),
+			SocketPath:        rapid.String().Draw(t, "socketPath"),
+			LabelCache: rapid.SampledFrom([]labelCacheScope{
+				labelCacheOff, labelCachePane, labelCacheGlobal,
+			}).Draw(t, "labelCache"),
+			LabelStrategy: rapid.SampledFrom([]labelStrategy{
+				"", labelStrategyHuffman, labelStrategyAlternating,
+			}).Draw(t, "labelStrategy"),
+			SelectionHistory: rapid.Bool().Draw(t, "selectionHistory"),
+			AutoSelect:       rapid.Bool().Draw(t, "autoSelect"),
+			AutoSelectRegex:  rapid.String().Draw(t, "autoSelectRegex"),
+			Select: selectSpec{
+				Regex: rapid.StringN(1, -1, -1).Draw(t, "selectRegex"),
+				Index: rapid.Int().Filter(func(i int) bool {
+					return i != 0
+				}).Draw(t, "selectIndex"),
+			},
+			Keys: rapid.SampledFrom([]keySequence{
+				"", "a", "<Tab>ab<Enter>", "<lt><S-a>",
+			}).Draw(t, "keys"),
+			Print:          rapid.Bool().Draw(t, "print"),
+			PrintRegexName: rapid.Bool().Draw(t, "printRegexName"),
+			RegexOnly:      rapid.String().Draw(t, "regexOnly"),
+			Popup:          rapid.Bool().Draw(t, "popup"),
+			ControlMode:    rapid.Bool().Draw(t, "controlMode"),
+			HistoryLines:   rapid.Int().Draw(t, "historyLines"),
+			Scope: rapid.SampledFrom([]captureScope{
+				"", captureScopePane, captureScopeWindow,
+				captureScopeSession, captureScopeServer,
+				captureScopeBuffers,
+			}).Draw(t, "scope"),
 		}
 	})
 }
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	tcell "github.com/gdamore/tcell/v3"
+	"go.uber.org/multierr"
+)
+
+const _execUsage = `usage: %[1]v exec [options] -- COMMAND [ARGS ...]
+
+Runs COMMAND, and shows the overlay on the terminal for its output.
+Runs the action on the selected text like %[1]v does for text in a
+tmux pane.
+
+	%[1]v exec -- git log --oneline -50
+
+To show the overlay in a tmux popup, run it inside one.
+
+	bind-key L display-popup -E '%[1]v exec -- git log --oneline -50'
+
+The following flags are available. They work the same as they do for
+%[1]v; see '%[1]v -help' for details.
+
+	-action COMMAND
+	-shift-action COMMAND
+	-named-action NAME:COMMAND
+	-bind NAME:KEY
+	-regex-action NAME:COMMAND
+	-regex-shift-action NAME:COMMAND
+	-regex-menu NAME:ACTIONS
+	-print
+	-regex NAME:PATTERN
+	-regex-only NAME
+	-alphabet STRING
+	-label-strategy STRATEGY
+	-auto-select
+	-auto-select-regex NAME
+	-select NAME:INDEX
+	-keys KEYS
+	-print-regex-name
+	-tmux PATH
+	-socket-name NAME
+	-socket-path PATH
+
+Inside tmux, the @fastcopy-* tmux options apply as well.
+Outside tmux, one of -action or -print is required.
+`
+
+// runExec implements the exec subcommand.
+func runExec(cmd *mainCmd, args []string) (err error) {
+	cmd.init()
+
+	var cfg config
+	flag := flag.NewFlagSet(_name+" exec", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.Output(), _execUsage, _name)
+	}
+	cfg.registerPickFlags(flag)
+	flag.StringVar(&cfg.Action, "action", "", "")
+	flag.StringVar(&cfg.ShiftAction, "shift-action", "", "")
+	flag.Var(&cfg.NamedActions, "named-action", "")
+	flag.Var(&cfg.Bindings, "bind", "")
+	flag.Var(&cfg.RegexActions, "regex-action", "")
+	flag.Var(&cfg.RegexShiftActions, "regex-shift-action", "")
+	flag.Var(&cfg.RegexMenus, "regex-menu", "")
+	flag.BoolVar(&cfg.Print, "print", false, "")
+	flag.StringVar(&cfg.Tmux, "tmux", "tmux", "")
+	flag.StringVar(&cfg.SocketName, "socket-name", "", "")
+	flag.StringVar(&cfg.SocketPath, "socket-path", "", "")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+
+	args = flag.Args()
+	if len(args) == 0 {
+		return errors.New("please specify a command to run")
+	}
+
+	logger := log.New(cmd.Stderr)
+	inTmux := len(cmd.Getenv("TMUX")) > 0 ||
+		len(cfg.SocketName) > 0 ||
+		len(cfg.SocketPath) > 0
+	newAction := &actionFactory{
+		Log:     logger,
+		Environ: cmd.Environ,
+		Getwd:   os.Getwd,
+	}
+	if inTmux {
+		tmuxDriver := cmd.newTmuxDriver(&cfg)
+		if c, ok := tmuxDriver.(io.Closer); ok {
+			defer multierr.AppendInvoke(&err, multierr.Close(c))
+		}
+		tmuxDriver.SetLogger(logger.WithName("tmux"))
+
+		version, err := tmux.CheckVersion(tmuxDriver)
+		if err != nil {
+			return err
+		}
+		if err := cfg.loadOptions(tmuxDriver); err != nil {
+			return err
+		}
+		cfg.FillFrom(defaultConfig())
+		newAction.Tmux = tmuxDriver
+		newAction.Version = version
+	} else if len(cfg.Action) == 0 && !cfg.Print {
+		return errors.New("-action or -print is required outside tmux")
+	}
+
+	text, err := runCommand(cmd.Stdin, args)
+	if err != nil {
+		return err
+	}
+
+	sel, err := (&picker{
+		Log:       logger,
+		NewScreen: tcell.NewScreen,
+	}).Pick(&cfg, text)
+	if err != nil {
+		return err
+	}
+
+	if len(sel.Text) == 0 {
+		if cfg.Print {
+			return _errCancelled
+		}
+		return nil
+	}
+
+	if cfg.Print {
+		return printSelection(cmd.Stdout, &cfg, sel)
+	}
+
+	// Run the action in the current directory against the pane we were
+	// run from, if any.
+	targetPane := &tmux.PaneInfo{ID: cmd.Getenv("TMUX_PANE")}
+	return runAction(newAction.New, &cfg, targetPane, sel)
+}
+
+// runCommand runs the given command, and returns everything that it wrote to
+// stdout and stderr.
+//
+// The output is returned even if the command exits with a non-zero status,
+// since it may have useful text in it.
+func runCommand(stdin io.Reader, args []string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && out.Len() > 0) {
+		return "", fmt.Errorf("run %q: %v", args[0], err)
+	}
+	return out.String(), nil
+}
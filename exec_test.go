@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/envtest"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExecCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		args []string
+
+		want     string
+		wantCode int // exit code if non-zero
+		wantErr  string
+	}{
+		{
+			desc: "print",
+			args: []string{"-print", "-select", "gitsha:last", "--", "echo", "4f3c2a1 a09be41f"},
+			want: "a09be41f\n",
+		},
+		{
+			desc: "keys",
+			args: []string{"-print", "-regex-only", "gitsha", "-alphabet", "ab", "-keys", "a", "--", "echo", "4f3c2a1 a09be41f"},
+			want: "4f3c2a1\n",
+		},
+		{
+			desc: "stderr",
+			args: []string{"-print", "-auto-select", "--", "sh", "-c", "echo 10.0.0.1 >&2"},
+			want: "10.0.0.1\n",
+		},
+		{
+			desc: "failing command with output",
+			args: []string{"-print", "-auto-select", "--", "sh", "-c", "echo 10.0.0.1; exit 1"},
+			want: "10.0.0.1\n",
+		},
+		{
+			desc:    "failing command without output",
+			args:    []string{"-print", "--", "sh", "-c", "exit 1"},
+			wantErr: `run "sh": exit status 1`,
+		},
+		{
+			desc:    "unknown command",
+			args:    []string{"-print", "--", "tmux-fastcopy-does-not-exist"},
+			wantErr: `run "tmux-fastcopy-does-not-exist"`,
+		},
+		{
+			desc:     "cancelled",
+			args:     []string{"-print", "-keys", "<Esc>", "--", "echo", "4f3c2a1"},
+			wantCode: 2,
+		},
+		{
+			desc:    "no command",
+			args:    []string{"-print"},
+			wantErr: "please specify a command to run",
+		},
+		{
+			desc:    "no action outside tmux",
+			args:    []string{"--", "echo", "4f3c2a1"},
+			wantErr: "-action or -print is required outside tmux",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			err := run(&mainCmd{
+				Stdin:  strings.NewReader(""),
+				Stdout: &stdout,
+				Stderr: &stderr,
+				Getenv: envtest.Empty.Getenv,
+			}, append([]string{"exec"}, tt.args...))
+
+			switch {
+			case len(tt.wantErr) > 0:
+				assert.ErrorContains(t, err, tt.wantErr)
+			case tt.wantCode != 0:
+				var exitErr *exitError
+				require.True(t, errors.As(err, &exitErr), "want exitError, got %v", err)
+				assert.Equal(t, tt.wantCode, exitErr.Code)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestExecCommand_action(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(mockCtrl)
+	mockTmux.EXPECT().Version().Return(tmux.Version{Major: 3, Minor: 4}, nil)
+	mockTmux.EXPECT().
+		ShowOptions(tmux.ShowOptionsRequest{Global: true}).
+		Return([]byte("@fastcopy-regex-word merge\n"), nil)
+
+	out := filepath.Join(t.TempDir(), "out")
+	var stdout, stderr bytes.Buffer
+	err := run(&mainCmd{
+		Stdin:   strings.NewReader(""),
+		Stdout:  &stdout,
+		Stderr:  &stderr,
+		Getenv:  envtest.MustPairs("TMUX", "/tmp/tmux-1000/default,1,0", "TMUX_PANE", "%3").Getenv,
+		Environ: os.Environ,
+		newTmuxDriver: func(*config) tmuxShellDriver {
+			return fakeTmux{mockTmux}
+		},
+	}, []string{
+		"exec",
+		"-action", "sh -c 'echo \"$0 $FASTCOPY_TARGET_PANE_ID\" > " + out + "' {}",
+		"-select", "word:1",
+		"--", "echo", "merge a09be41f",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, stdout.String())
+
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "merge %3\n", string(got))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/history"
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+	"github.com/abhinav/tmux-fastcopy/internal/statefile"
+)
+
+const (
+	_historyFile = "history.json"
+
+	// Number of texts listed by the history command.
+	_historyTopTexts = 10
+)
+
+// selectionHistory records selections made in the UI and uses them to weigh
+// hints.
+type selectionHistory struct {
+	Log     *log.Logger
+	History *history.History
+	Path    string // path to the history file
+}
+
+// openSelectionHistory loads the selection history.
+// It returns nil if the history is disabled or unavailable.
+func openSelectionHistory(logger *log.Logger, enabled bool, stateDir string) *selectionHistory {
+	if !enabled {
+		return nil
+	}
+
+	if len(stateDir) == 0 {
+		logger.Errorf("selection history: state directory unavailable")
+		return nil
+	}
+
+	path := filepath.Join(stateDir, _historyFile)
+	h, err := history.Load(path)
+	if err != nil {
+		logger.Errorf("selection history: %v", err)
+		return nil
+	}
+
+	return &selectionHistory{
+		Log:     logger,
+		History: h,
+		Path:    path,
+	}
+}
+
+// Weigh returns a function that weighs text on the screen
+// by how often similar text was selected before.
+//
+// Returns nil if the history is disabled.
+func (s *selectionHistory) Weigh() func(string, []fastcopy.Match) int {
+	if s == nil {
+		return nil
+	}
+
+	return func(text string, matches []fastcopy.Match) int {
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = m.Matcher
+		}
+		return len(matches) * s.History.Weight(text, names)
+	}
+}
+
+// Record records the selection in the history.
+func (s *selectionHistory) Record(sel fastcopy.Selection) {
+	if s == nil {
+		return
+	}
+
+	s.History.Record(sel.Text, sel.Matchers)
+	if err := s.History.Save(s.Path); err != nil {
+		s.Log.Errorf("selection history: %v", err)
+	}
+}
+
+const _historyUsage = `usage: %v history [options]
+
+Inspects the history of selections recorded with -selection-history.
+
+The following flags are available:
+
+	-json
+		print the full history as JSON.
+	-reset
+		delete the recorded history.
+`
+
+// runHistory implements the history subcommand.
+func runHistory(cmd *mainCmd, args []string) error {
+	flag := flag.NewFlagSet(_name+" history", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.Output(), _historyUsage, _name)
+	}
+	asJSON := flag.Bool("json", false, "")
+	reset := flag.Bool("reset", false, "")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+
+	if args := flag.Args(); len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %q", args)
+	}
+
+	stateDir, err := userStateDir(cmd.Getenv)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(stateDir, _historyFile)
+
+	if *reset {
+		return statefile.Remove(path)
+	}
+
+	h, err := history.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(cmd.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(h)
+	}
+
+	fmt.Fprintf(cmd.Stdout, "%d selections recorded in %v\n", h.Total, path)
+	if h.Total == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "REGEX\tSELECTIONS")
+	for _, c := range h.TopRegexes() {
+		fmt.Fprintf(tw, "%v\t%v\n", c.Value, c.Count)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TEXT\tSELECTIONS")
+	texts := h.TopTexts()
+	if len(texts) > _historyTopTexts {
+		texts = texts[:_historyTopTexts]
+	}
+	for _, c := range texts {
+		fmt.Fprintf(tw, "%q\t%v\n", c.Value, c.Count)
+	}
+	return tw.Flush()
+}
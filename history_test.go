@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/envtest"
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectionHistory(t *testing.T) {
+	t.Parallel()
+
+	log := logtest.NewLogger(t)
+	dir := t.TempDir()
+
+	assert.Nil(t, openSelectionHistory(log, false, dir), "disabled")
+	assert.Nil(t, openSelectionHistory(log, true, ""), "no state directory")
+
+	var disabled *selectionHistory
+	assert.Nil(t, disabled.Weigh(), "nil history must not weigh")
+	disabled.Record(fastcopy.Selection{Text: "foo", Matchers: []string{"x"}})
+
+	h := openSelectionHistory(log, true, dir)
+	require.NotNil(t, h)
+	h.Record(fastcopy.Selection{Text: "016ca97", Matchers: []string{"gitsha"}})
+
+	h = openSelectionHistory(log, true, dir)
+	require.NotNil(t, h)
+	weigh := h.Weigh()
+	require.NotNil(t, weigh)
+
+	sha := weigh("dbf2bb4", []fastcopy.Match{{Matcher: "gitsha"}})
+	num := weigh("1234", []fastcopy.Match{{Matcher: "int"}})
+	assert.Greater(t, sha, num)
+	assert.Equal(t, 2*num,
+		weigh("1234", []fastcopy.Match{{Matcher: "int"}, {Matcher: "int"}}),
+		"weight must scale with the number of matches")
+}
+
+func TestHistoryCommand(t *testing.T) {
+	t.Parallel()
+
+	stateHome := t.TempDir()
+	env := envtest.MustPairs("XDG_STATE_HOME", stateHome)
+	path := filepath.Join(stateHome, _name, _historyFile)
+
+	runHistory := func(t *testing.T, args ...string) string {
+		var stdout, stderr bytes.Buffer
+		err := run(&mainCmd{
+			Stdout: &stdout,
+			Stderr: &stderr,
+			Getenv: env.Getenv,
+		}, append([]string{"history"}, args...))
+		require.NoError(t, err)
+		assert.Empty(t, stderr.String())
+		return stdout.String()
+	}
+
+	assert.Contains(t, runHistory(t), "0 selections recorded")
+
+	h := openSelectionHistory(logtest.NewLogger(t), true, filepath.Dir(path))
+	require.NotNil(t, h)
+	h.Record(fastcopy.Selection{Text: "016ca97", Matchers: []string{"gitsha"}})
+	h.Record(fastcopy.Selection{Text: "/usr/bin/env", Matchers: []string{"path"}})
+	h.Record(fastcopy.Selection{Text: "016ca97", Matchers: []string{"gitsha"}})
+
+	out := runHistory(t)
+	assert.Contains(t, out, "3 selections recorded in "+path)
+	assert.Regexp(t, `gitsha\s+2`, out)
+	assert.Regexp(t, `path\s+1`, out)
+	assert.Regexp(t, `"016ca97"\s+2`, out)
+
+	assert.Contains(t, runHistory(t, "-json"), `"gitsha": 2`)
+
+	runHistory(t, "-reset")
+	assert.Contains(t, runHistory(t), "0 selections recorded")
+}
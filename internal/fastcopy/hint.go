@@ -29,10 +29,63 @@ type hint struct {
 	Selected bool
 }
 
-// generateHints generates a list of hints for the given text. It uses alphabet
-// to generate unique prefix-free labels for matche sin the text, where matches
-// are defined by the provided ranges.
-func generateHints(alphabet []rune, text string, matches []Match) []hint {
+// LabelStrategy specifies how labels are generated for hints.
+type LabelStrategy int
+
+const (
+	// HuffmanLabels generates labels such that the text that appears the
+	// most gets the shortest labels.
+	//
+	// This is the default.
+	HuffmanLabels LabelStrategy = iota
+
+	// AlternatingLabels generates labels where consecutive letters
+	// alternate between the first and second halves of the alphabet.
+	// If the alphabet is listed in keyboard order from left to right,
+	// this alternates between the left and right hands.
+	//
+	// Text that appears the most still gets the shortest labels, but
+	// labels may be longer than with HuffmanLabels. If either half of the
+	// alphabet has fewer than two letters, this falls back to
+	// HuffmanLabels.
+	AlternatingLabels
+)
+
+func (s LabelStrategy) labelFunc() func(int, []int) [][]int {
+	switch s {
+	case AlternatingLabels:
+		return alternatingLabel
+	default:
+		return huffman.Label
+	}
+}
+
+// hintOptions configures generateHints.
+type hintOptions struct {
+	// Alphabet used to generate labels.
+	Alphabet []rune
+
+	// Label generates unique prefix-free labels for items with the given
+	// frequencies, returning indexes into the alphabet for each label.
+	// Defaults to huffman.Label.
+	Label func(alphabetSize int, freqs []int) [][]int
+
+	// Weigh reports the relative weight of each unique text. Heavier text
+	// gets shorter labels. Defaults to the number of times the text
+	// appears.
+	Weigh func(string, []Match) int
+}
+
+// generateHints generates a list of hints for the given text. It uses the
+// alphabet to generate unique prefix-free labels for matche sin the text,
+// where matches are defined by the provided ranges.
+func generateHints(opts hintOptions, text string, matches []Match) []hint {
+	alphabet := opts.Alphabet
+	label := opts.Label
+	if label == nil {
+		label = huffman.Label
+	}
+
 	labelFrom := func(indexes []int) string {
 		label := make([]rune, len(indexes))
 		for i, idx := range indexes {
@@ -44,8 +97,7 @@ func generateHints(alphabet []rune, text string, matches []Match) []hint {
 	// Grouping of match ranges by their matched text.
 	byText := make(map[string][]Match)
 	for _, m := range matches {
-		r := m.Range
-		match := text[r.Start:r.End]
+		match := m.TextIn(text)
 		byText[match] = append(byText[match], m)
 	}
 
@@ -57,11 +109,15 @@ func generateHints(alphabet []rune, text string, matches []Match) []hint {
 
 	freqs := make([]int, len(uniqueMatches))
 	for i, t := range uniqueMatches {
-		freqs[i] = len(byText[t])
+		if opts.Weigh != nil {
+			freqs[i] = max(opts.Weigh(t, byText[t]), 1)
+		} else {
+			freqs[i] = len(byText[t])
+		}
 	}
 
 	hints := make([]hint, len(uniqueMatches))
-	for i, labelIxes := range huffman.Label(len(alphabet), freqs) {
+	for i, labelIxes := range label(len(alphabet), freqs) {
 		t := uniqueMatches[i]
 		hints[i] = hint{
 			Label:   labelFrom(labelIxes),
@@ -73,6 +129,120 @@ func generateHints(alphabet []rune, text string, matches []Match) []hint {
 	return hints
 }
 
+// alternatingLabel generates unique prefix-free labels for items with the
+// given frequencies like huffman.Label, except that consecutive letters in a
+// label alternate between the first and second halves of the alphabet.
+//
+// Items with higher frequencies get shorter labels.
+func alternatingLabel(alphabetSize int, freqs []int) [][]int {
+	// The first half of the alphabet is [0, split),
+	// and the second half is [split, alphabetSize).
+	split := (alphabetSize + 1) / 2
+	if split < 2 || alphabetSize-split < 2 {
+		return huffman.Label(alphabetSize, freqs)
+	}
+
+	// Labels form a tree where the children of a letter from one half
+	// are the letters of the other half. Labels are the leaves of this
+	// tree, so they're prefix-free.
+	//
+	// Start with all single letter labels, and expand the shortest ones
+	// until we have enough leaves. leaves is always ordered by length.
+	leaves := make([][]int, alphabetSize)
+	for i := range leaves {
+		leaves[i] = []int{i}
+	}
+	for len(leaves) < len(freqs) {
+		parent := leaves[0]
+		leaves = leaves[1:]
+
+		lo, hi := 0, split
+		if parent[len(parent)-1] < split {
+			lo, hi = split, alphabetSize
+		}
+		for i := lo; i < hi; i++ {
+			label := make([]int, len(parent), len(parent)+1)
+			copy(label, parent)
+			leaves = append(leaves, append(label, i))
+		}
+	}
+
+	// Give the shortest labels to the most frequent items.
+	order := make([]int, len(freqs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return freqs[order[i]] > freqs[order[j]]
+	})
+
+	labels := make([][]int, len(freqs))
+	for rank, idx := range order {
+		labels[idx] = leaves[rank]
+	}
+	return labels
+}
+
+// reuseLabels reassigns labels between the given hints so that text which was
+// previously assigned a label gets the same label again, if that label is
+// still in use.
+//
+// This only shuffles labels that are already assigned to hints, so the
+// labels remain prefix-free.
+func reuseLabels(hints []hint, previous map[string]string) {
+	if len(previous) == 0 {
+		return
+	}
+
+	available := make(map[string]struct{}, len(hints))
+	for _, h := range hints {
+		available[h.Label] = struct{}{}
+	}
+
+	labels := make([]string, len(hints))
+	claimed := make(map[string]struct{}, len(hints))
+	claim := func(i int, label string) bool {
+		if _, ok := available[label]; !ok {
+			return false
+		}
+		if _, ok := claimed[label]; ok {
+			return false
+		}
+		labels[i] = label
+		claimed[label] = struct{}{}
+		return true
+	}
+
+	// Text that had a label before gets first dibs on that label.
+	for i, h := range hints {
+		if label, ok := previous[h.Text]; ok {
+			claim(i, label)
+		}
+	}
+
+	// Everything else keeps its own label if nobody took it.
+	var unclaimed []int
+	for i, h := range hints {
+		if len(labels[i]) == 0 && !claim(i, h.Label) {
+			unclaimed = append(unclaimed, i)
+		}
+	}
+
+	// And the rest takes the leftovers in order.
+	for _, h := range hints {
+		if len(unclaimed) == 0 {
+			break
+		}
+		if claim(unclaimed[0], h.Label) {
+			unclaimed = unclaimed[1:]
+		}
+	}
+
+	for i := range hints {
+		hints[i].Label = labels[i]
+	}
+}
+
 // AnnotationStyle is the style of annotations for hints and matched text.
 type AnnotationStyle struct {
 	// Matched text that is still a candidate for selection.
@@ -100,43 +270,57 @@ func (h *hint) Annotations(input string, style AnnotationStyle) (anns []ui.TextA
 	}
 
 	for _, match := range h.Matches {
-		pos := match.Range
-		// Show the label only if there's no input, or if the input
-		// matches all or part of the label.
-		if matched {
-			i := 0
-
-			// Highlight the portion of the label already typed by
-			// the user.
-			if len(input) > 0 {
-				anns = append(anns, ui.OverlayTextAnnotation{
-					Offset:  pos.Start,
-					Overlay: input,
-					Style:   style.LabelTyped,
-				})
-				i += len(input)
-			}
+		ranges := append([]Range{match.Range}, match.Wrapped...)
 
-			// Highlight the portion of the label yet to be typed.
-			if i < len(h.Label) {
-				anns = append(anns, ui.OverlayTextAnnotation{
-					Offset:  pos.Start + len(input),
-					Overlay: h.Label[i:],
-					Style:   style.Label,
-				})
+		// Show the label in the first part of a wrapped match that has
+		// room for it.
+		labelAt := 0
+		for i, r := range ranges {
+			if r.Len() >= len(h.Label) {
+				labelAt = i
+				break
 			}
-
-			pos.Start += len(h.Label)
 		}
 
-		// Don't show the rest of the matched text if the label is
-		// longer than the text.
-		if pos.End > pos.Start {
-			anns = append(anns, ui.StyleTextAnnotation{
-				Offset: pos.Start,
-				Length: pos.End - pos.Start,
-				Style:  matchStyle,
-			})
+		for i, pos := range ranges {
+			// Show the label only if there's no input, or if the
+			// input matches all or part of the label.
+			if matched && i == labelAt {
+				j := 0
+
+				// Highlight the portion of the label already
+				// typed by the user.
+				if len(input) > 0 {
+					anns = append(anns, ui.OverlayTextAnnotation{
+						Offset:  pos.Start,
+						Overlay: input,
+						Style:   style.LabelTyped,
+					})
+					j += len(input)
+				}
+
+				// Highlight the portion of the label yet to be
+				// typed.
+				if j < len(h.Label) {
+					anns = append(anns, ui.OverlayTextAnnotation{
+						Offset:  pos.Start + len(input),
+						Overlay: h.Label[j:],
+						Style:   style.Label,
+					})
+				}
+
+				pos.Start += len(h.Label)
+			}
+
+			// Don't show the rest of the matched text if the label
+			// is longer than the text.
+			if pos.End > pos.Start {
+				anns = append(anns, ui.StyleTextAnnotation{
+					Offset: pos.Start,
+					Length: pos.End - pos.Start,
+					Style:  matchStyle,
+				})
+			}
 		}
 	}
 
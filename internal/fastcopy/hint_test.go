@@ -1,12 +1,15 @@
 package fastcopy
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/abhinav/tmux-fastcopy/internal/ui"
 	tcell "github.com/gdamore/tcell/v3"
 	tcolor "github.com/gdamore/tcell/v3/color"
 	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/algorithm/huffman"
+	"pgregory.net/rapid"
 )
 
 func TestGenerateHints(t *testing.T) {
@@ -29,14 +32,14 @@ func TestGenerateHints(t *testing.T) {
 			desc: "single match",
 			text: "foo bar",
 			matches: []Match{
-				{"name", Range{1, 3}}, // f(oo)
+				{Matcher: "name", Range: Range{1, 3}}, // f(oo)
 			},
 			want: []hint{
 				{
 					Label: "a",
 					Text:  "oo",
 					Matches: []Match{
-						{"name", Range{1, 3}}, // f(oo)
+						{Matcher: "name", Range: Range{1, 3}}, // f(oo)
 					},
 				},
 			},
@@ -45,16 +48,16 @@ func TestGenerateHints(t *testing.T) {
 			desc: "duplicated match",
 			text: "foo bar baz qux",
 			matches: []Match{
-				{"name1", Range{4, 6}},  // (ba)r
-				{"name2", Range{8, 10}}, // (ba)z
+				{Matcher: "name1", Range: Range{4, 6}},  // (ba)r
+				{Matcher: "name2", Range: Range{8, 10}}, // (ba)z
 			},
 			want: []hint{
 				{
 					Label: "a",
 					Text:  "ba",
 					Matches: []Match{
-						{"name1", Range{4, 6}},  // (ba)r
-						{"name2", Range{8, 10}}, // (ba)z
+						{Matcher: "name1", Range: Range{4, 6}},  // (ba)r
+						{Matcher: "name2", Range: Range{8, 10}}, // (ba)z
 					},
 				},
 			},
@@ -63,32 +66,64 @@ func TestGenerateHints(t *testing.T) {
 			desc: "multiple matches",
 			text: "foo bar baz qux",
 			matches: []Match{
-				{"p", Range{0, 3}},   // (foo)
-				{"q", Range{4, 6}},   // (ba)r
-				{"r", Range{8, 10}},  // (ba)z
-				{"s", Range{13, 15}}, // q(ux)
+				{Matcher: "p", Range: Range{0, 3}},   // (foo)
+				{Matcher: "q", Range: Range{4, 6}},   // (ba)r
+				{Matcher: "r", Range: Range{8, 10}},  // (ba)z
+				{Matcher: "s", Range: Range{13, 15}}, // q(ux)
 			},
 			want: []hint{
 				{
 					Label: "c",
 					Text:  "ba",
 					Matches: []Match{
-						{"q", Range{4, 6}},  // (ba)r
-						{"r", Range{8, 10}}, // (ba)z
+						{Matcher: "q", Range: Range{4, 6}},  // (ba)r
+						{Matcher: "r", Range: Range{8, 10}}, // (ba)z
 					},
 				},
 				{
 					Label: "a",
 					Text:  "foo",
 					Matches: []Match{
-						{"p", Range{0, 3}}, // (foo)
+						{Matcher: "p", Range: Range{0, 3}}, // (foo)
 					},
 				},
 				{
 					Label: "b",
 					Text:  "ux",
 					Matches: []Match{
-						{"s", Range{13, 15}}, // q(ux)
+						{Matcher: "s", Range: Range{13, 15}}, // q(ux)
+					},
+				},
+			},
+		},
+		{
+			desc: "wrapped match",
+			text: "foo ba\nr baz",
+			matches: []Match{
+				{
+					Matcher: "name1",
+					Range:   Range{4, 6},
+					Wrapped: []Range{{7, 8}},
+				}, // (ba)\n(r)
+				{Matcher: "name2", Range: Range{10, 12}}, // b(az)
+			},
+			want: []hint{
+				{
+					Label: "a",
+					Text:  "az",
+					Matches: []Match{
+						{Matcher: "name2", Range: Range{10, 12}}, // b(az)
+					},
+				},
+				{
+					Label: "b",
+					Text:  "bar",
+					Matches: []Match{
+						{
+							Matcher: "name1",
+							Range:   Range{4, 6},
+							Wrapped: []Range{{7, 8}},
+						}, // (ba)\n(r)
 					},
 				},
 			},
@@ -100,7 +135,215 @@ func TestGenerateHints(t *testing.T) {
 		t.Run(tt.desc, func(t *testing.T) {
 			t.Parallel()
 
-			got := generateHints(alphabet, tt.text, tt.matches)
+			got := generateHints(hintOptions{Alphabet: alphabet}, tt.text, tt.matches)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGenerateHints_weighted(t *testing.T) {
+	t.Parallel()
+
+	text := "foo bar baz"
+	matches := []Match{
+		{Matcher: "x", Range: Range{0, 3}},  // foo
+		{Matcher: "y", Range: Range{4, 7}},  // bar
+		{Matcher: "z", Range: Range{8, 11}}, // baz
+	}
+
+	// With two letters, one of the three items gets a single letter label.
+	// Make sure that it's the heaviest item.
+	got := generateHints(hintOptions{
+		Alphabet: []rune("ab"),
+		Weigh: func(_ string, ms []Match) int {
+			if ms[0].Matcher == "z" {
+				return 10
+			}
+			return 1
+		},
+	}, text, matches)
+
+	labels := make(map[string]string)
+	for _, h := range got {
+		labels[h.Text] = h.Label
+	}
+	assert.Len(t, labels["baz"], 1, "heaviest text must get the shortest label")
+	assert.Len(t, labels["foo"], 2)
+	assert.Len(t, labels["bar"], 2)
+}
+
+func TestAlternatingLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		size  int
+		freqs []int
+		want  [][]int
+	}{
+		{
+			desc:  "single letters",
+			size:  4,
+			freqs: []int{1, 1, 1},
+			want:  [][]int{{0}, {1}, {2}},
+		},
+		{
+			desc:  "frequent first",
+			size:  4,
+			freqs: []int{1, 5, 1, 1, 1},
+			want:  [][]int{{2}, {1}, {3}, {0, 2}, {0, 3}},
+		},
+		{
+			desc:  "right hand expands to left",
+			size:  4,
+			freqs: []int{1, 1, 1, 1, 1, 1, 1},
+			want:  [][]int{{3}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 0}, {2, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, alternatingLabel(tt.size, tt.freqs))
+		})
+	}
+}
+
+func TestAlternatingLabel_fallback(t *testing.T) {
+	t.Parallel()
+
+	// Three letters can't be split into two halves of two or more.
+	freqs := []int{3, 1, 1, 1, 2}
+	assert.Equal(t, huffman.Label(3, freqs), alternatingLabel(3, freqs))
+}
+
+func TestAlternatingLabel_rapid(t *testing.T) {
+	t.Parallel()
+
+	rapid.Check(t, func(t *rapid.T) {
+		size := rapid.IntRange(4, 26).Draw(t, "size")
+		freqs := rapid.SliceOfN(rapid.IntRange(1, 100), 0, 200).Draw(t, "freqs")
+
+		labels := alternatingLabel(size, freqs)
+		if len(labels) != len(freqs) {
+			t.Fatalf("expected %d labels, got %d", len(freqs), len(labels))
+		}
+
+		split := (size + 1) / 2
+		for _, label := range labels {
+			for j, idx := range label {
+				if idx < 0 || idx >= size {
+					t.Fatalf("label %v: index %d out of range", label, idx)
+				}
+				if j > 0 && (label[j-1] < split) == (idx < split) {
+					t.Fatalf("label %v does not alternate halves", label)
+				}
+			}
+		}
+
+		// Labels must be prefix-free for selection to work.
+		for i, a := range labels {
+			for j, b := range labels {
+				if i != j && len(a) <= len(b) && slices.Equal(a, b[:len(a)]) {
+					t.Fatalf("label %v is a prefix of %v", a, b)
+				}
+			}
+		}
+
+		// More frequent items never get longer labels.
+		for i := range labels {
+			for j := range labels {
+				if freqs[i] > freqs[j] && len(labels[i]) > len(labels[j]) {
+					t.Fatalf("label %v (freq %d) longer than %v (freq %d)",
+						labels[i], freqs[i], labels[j], freqs[j])
+				}
+			}
+		}
+	})
+}
+
+func TestReuseLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		give     []hint
+		previous map[string]string
+		want     []string // labels in order
+	}{
+		{
+			desc: "no previous labels",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "b", Text: "bar"},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			desc: "swap",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "b", Text: "bar"},
+			},
+			previous: map[string]string{"bar": "a"},
+			want:     []string{"b", "a"},
+		},
+		{
+			desc: "previous label unavailable",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "b", Text: "bar"},
+			},
+			previous: map[string]string{"bar": "cd"},
+			want:     []string{"a", "b"},
+		},
+		{
+			desc: "text no longer present",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "b", Text: "bar"},
+			},
+			previous: map[string]string{"baz": "a"},
+			want:     []string{"a", "b"},
+		},
+		{
+			desc: "keep own label",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "b", Text: "bar"},
+				{Label: "c", Text: "baz"},
+			},
+			previous: map[string]string{"baz": "a"},
+			want:     []string{"c", "b", "a"},
+		},
+		{
+			desc: "conflicting previous labels",
+			give: []hint{
+				{Label: "a", Text: "foo"},
+				{Label: "ba", Text: "bar"},
+				{Label: "bb", Text: "baz"},
+			},
+			previous: map[string]string{
+				"foo": "bb",
+				"bar": "bb",
+			},
+			want: []string{"bb", "ba", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			reuseLabels(tt.give, tt.previous)
+
+			got := make([]string, len(tt.give))
+			for i, h := range tt.give {
+				got[i] = h.Label
+			}
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -128,8 +371,8 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "a",
 				Text:  "foo",
 				Matches: []Match{
-					{"x", Range{0, 3}},
-					{"y", Range{7, 10}},
+					{Matcher: "x", Range: Range{0, 3}},
+					{Matcher: "y", Range: Range{7, 10}},
 				},
 			},
 			// [a]oo
@@ -162,7 +405,7 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "a",
 				Text:  "foo",
 				Matches: []Match{
-					{"x", Range{0, 3}},
+					{Matcher: "x", Range: Range{0, 3}},
 				},
 			},
 			input: "a",
@@ -185,7 +428,7 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "ab",
 				Text:  "foobar",
 				Matches: []Match{
-					{"x", Range{1, 7}},
+					{Matcher: "x", Range: Range{1, 7}},
 				},
 			},
 			want: []ui.TextAnnotation{
@@ -207,7 +450,7 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "ab",
 				Text:  "foobar",
 				Matches: []Match{
-					{"x", Range{1, 7}},
+					{Matcher: "x", Range: Range{1, 7}},
 				},
 			},
 			input: "a",
@@ -235,7 +478,7 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "ab",
 				Text:  "foobar",
 				Matches: []Match{
-					{"x", Range{1, 7}},
+					{Matcher: "x", Range: Range{1, 7}},
 				},
 			},
 			input: "x",
@@ -253,7 +496,7 @@ func TestHintAnnotations(t *testing.T) {
 				Label: "abcd",
 				Text:  "foo",
 				Matches: []Match{
-					{"x", Range{0, 3}},
+					{Matcher: "x", Range: Range{0, 3}},
 				},
 			},
 			want: []ui.TextAnnotation{
@@ -264,6 +507,62 @@ func TestHintAnnotations(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "wrapped",
+			give: hint{
+				Label: "a",
+				Text:  "foobar",
+				Matches: []Match{
+					{Matcher: "x", Range: Range{0, 3}, Wrapped: []Range{{4, 7}}},
+				},
+			},
+			// [a]oo\nbar
+			want: []ui.TextAnnotation{
+				ui.OverlayTextAnnotation{
+					Offset:  0,
+					Overlay: "a",
+					Style:   style.Label,
+				},
+				ui.StyleTextAnnotation{
+					Offset: 1,
+					Length: 2,
+					Style:  style.Match,
+				},
+				ui.StyleTextAnnotation{
+					Offset: 4,
+					Length: 3,
+					Style:  style.Match,
+				},
+			},
+		},
+		{
+			desc: "wrapped/label doesn't fit",
+			give: hint{
+				Label: "ab",
+				Text:  "foobar",
+				Matches: []Match{
+					{Matcher: "x", Range: Range{2, 3}, Wrapped: []Range{{4, 9}}},
+				},
+			},
+			// f\n[ab]oba
+			want: []ui.TextAnnotation{
+				ui.StyleTextAnnotation{
+					Offset: 2,
+					Length: 1,
+					Style:  style.Match,
+				},
+				ui.OverlayTextAnnotation{
+					Offset:  4,
+					Overlay: "ab",
+					Style:   style.Label,
+				},
+				ui.StyleTextAnnotation{
+					Offset: 6,
+					Length: 3,
+					Style:  style.Match,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
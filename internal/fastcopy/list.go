@@ -0,0 +1,167 @@
+package fastcopy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/ui"
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/rivo/uniseg"
+)
+
+// Maximum width of the line of context shown for each entry in a ListWidget.
+const _listContextWidth = 60
+
+// ListWidget shows the hints of a Widget as a list instead of in place in
+// the text. Matched text is grouped by the name of the regex that matched it,
+// and each entry shows its label, the number of times the text was matched,
+// and the line that it was first matched on.
+//
+//	gitsha (2)
+//	  a   4f3c2a1   2  commit 4f3c2a1 Fix the thing
+//	  sd  a09be41f  1  Merge a09be41f into main
+//
+// Text matched by more than one regex is listed under each of them.
+//
+// A ListWidget shares its labels, input, and selections with the Widget it
+// was built from, so the two may be swapped freely.
+type ListWidget struct {
+	w *Widget
+}
+
+var _ ui.Tall = (*ListWidget)(nil)
+
+// List returns a ListWidget that shows the hints of this widget as a list.
+func (w *Widget) List() *ListWidget {
+	return &ListWidget{w: w}
+}
+
+// Draw draws the list onto the provided view.
+func (l *ListWidget) Draw(view ui.View) {
+	l.w.listw.Draw(view)
+}
+
+// Height reports the number of rows needed to draw the list in full on a view
+// of the given width.
+func (l *ListWidget) Height(width int) int {
+	return l.w.listw.Height(width)
+}
+
+// HandleEvent handles input for the list the same way as the Widget it was
+// built from.
+func (l *ListWidget) HandleEvent(ev tcell.Event) (handled bool) {
+	return l.w.HandleEvent(ev)
+}
+
+// listEntry is a single entry of a ListWidget.
+type listEntry struct {
+	Hint  int   // index of the hint
+	First Match // first match for this entry
+	Count int   // number of matches
+}
+
+// buildList lays out the given hints for text as a list for ListWidget.
+//
+// It returns the text of the list, and for each hint, matches for where its
+// entries are in that text. Matches cover the label column and the text.
+func buildList(text string, hints []hint) (string, [][]Match) {
+	groups := make(map[string][]*listEntry)
+	var labelLen int
+	for i, h := range hints {
+		labelLen = max(labelLen, len(h.Label))
+
+		entries := make(map[string]*listEntry)
+		for _, m := range h.Matches {
+			e, ok := entries[m.Matcher]
+			if !ok {
+				e = &listEntry{Hint: i, First: m}
+				entries[m.Matcher] = e
+				groups[m.Matcher] = append(groups[m.Matcher], e)
+			}
+			e.Count++
+			if m.Range.Start < e.First.Range.Start {
+				e.First = m
+			}
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		sb      strings.Builder
+		matches = make([][]Match, len(hints))
+	)
+	for _, name := range names {
+		entries := groups[name]
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].First.Range.Start < entries[j].First.Range.Start
+		})
+
+		var textWidth, countWidth int
+		for _, e := range entries {
+			textWidth = max(textWidth, uniseg.StringWidth(hints[e.Hint].Text))
+			countWidth = max(countWidth, len(strconv.Itoa(e.Count)))
+		}
+
+		fmt.Fprintf(&sb, "%v (%d)\n", name, len(entries))
+		for _, e := range entries {
+			h := hints[e.Hint]
+
+			sb.WriteString("  ")
+			start := sb.Len()
+			// The label is drawn over these spaces.
+			sb.WriteString(strings.Repeat(" ", labelLen+2))
+			// Matched text may span lines, but entries must not.
+			sb.WriteString(strings.ReplaceAll(h.Text, "\n", " "))
+			matches[e.Hint] = append(matches[e.Hint], Match{
+				Matcher: name,
+				Range:   Range{Start: start, End: sb.Len()},
+			})
+
+			sb.WriteString(strings.Repeat(" ", textWidth-uniseg.StringWidth(h.Text)+2))
+			fmt.Fprintf(&sb, "%*d", countWidth, e.Count)
+			sb.WriteString("  ")
+			sb.WriteString(contextLine(text, e.First.Range))
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String(), matches
+}
+
+// contextLine returns the line of text that the given range starts on,
+// without surrounding whitespace, and shortened to _listContextWidth.
+func contextLine(text string, r Range) string {
+	start := strings.LastIndexByte(text[:r.Start], '\n') + 1
+	end := len(text)
+	if idx := strings.IndexByte(text[r.Start:], '\n'); idx >= 0 {
+		end = r.Start + idx
+	}
+	line := strings.TrimSpace(text[start:end])
+
+	if uniseg.StringWidth(line) <= _listContextWidth {
+		return line
+	}
+
+	var (
+		sb    strings.Builder
+		width int
+	)
+	g := uniseg.NewGraphemes(line)
+	for g.Next() {
+		// Leave room for the ellipsis.
+		if width+g.Width() > _listContextWidth-1 {
+			break
+		}
+		sb.WriteString(g.Str())
+		width += g.Width()
+	}
+	sb.WriteString("…")
+	return sb.String()
+}
@@ -0,0 +1,167 @@
+package fastcopy
+
+import (
+	"strings"
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func TestBuildList(t *testing.T) {
+	t.Parallel()
+
+	//  0         1         2
+	//  0123456789012345678901234
+	// "see 1234 and deadbeef\n"
+	// "  then 1234 again\n"
+	text := "see 1234 and deadbeef\n  then 1234 again\n"
+	hints := []hint{
+		{
+			Label: "a",
+			Text:  "1234",
+			Matches: []Match{
+				{Matcher: "int", Range: Range{4, 8}},
+				{Matcher: "int", Range: Range{29, 33}},
+				{Matcher: "hex", Range: Range{29, 33}},
+			},
+		},
+		{
+			Label:   "bc",
+			Text:    "deadbeef",
+			Matches: []Match{{Matcher: "hex", Range: Range{13, 21}}},
+		},
+	}
+
+	got, matches := buildList(text, hints)
+	assert.Equal(t, strings.Join([]string{
+		"hex (2)",
+		"      deadbeef  1  see 1234 and deadbeef",
+		"      1234      1  then 1234 again",
+		"int (1)",
+		"      1234  2  see 1234 and deadbeef",
+		"",
+	}, "\n"), got)
+
+	require.Len(t, matches, 2)
+	for i, ms := range matches {
+		for _, m := range ms {
+			assert.Equal(t, "    "+hints[i].Text, got[m.Range.Start:m.Range.End],
+				"match %v must cover the label column and the text", m)
+		}
+	}
+	assert.Len(t, matches[0], 2, "listed under both regexes")
+	assert.Len(t, matches[1], 1)
+}
+
+func TestContextLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		text string
+		give Range
+		want string
+	}{
+		{
+			desc: "middle line",
+			text: "foo\n  bar baz  \nqux",
+			give: Range{10, 13},
+			want: "bar baz",
+		},
+		{
+			desc: "last line without newline",
+			text: "foo\nqux",
+			give: Range{4, 7},
+			want: "qux",
+		},
+		{
+			desc: "long line",
+			text: strings.Repeat("x", 100),
+			give: Range{0, 1},
+			want: strings.Repeat("x", _listContextWidth-1) + "…",
+		},
+		{
+			desc: "wide characters",
+			text: strings.Repeat("文", 40),
+			give: Range{0, 3},
+			want: strings.Repeat("文", _listContextWidth/2-1) + "…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, contextLine(tt.text, tt.give))
+		})
+	}
+}
+
+func TestListWidget(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	handler := NewMockHandler(mockCtrl)
+	w := (&WidgetConfig{
+		Text: "foo bar\nbaz",
+		Matches: []Match{
+			{Matcher: "x", Range: Range{0, 3}},  // foo
+			{Matcher: "y", Range: Range{8, 11}}, // baz
+		},
+		HintAlphabet: []rune("ab"),
+		Handler:      handler,
+		Style:        sampleStyle(),
+	}).Build()
+	labels := w.Labels()
+
+	list := w.List()
+	view := newGridView(20, 5)
+	list.Draw(view)
+	assert.Equal(t, []string{
+		"x (1)",
+		"  " + labels["foo"] + "  foo  1  foo bar",
+		"y (1)",
+		"  " + labels["baz"] + "  baz  1  baz",
+		"",
+	}, view.Rows())
+	assert.Equal(t, 4, list.Height(20))
+	assert.Equal(t, 6, list.Height(10), "entries must wrap")
+
+	handler.EXPECT().
+		HandleSelection(Selection{Text: "baz", Matchers: []string{"y"}})
+	assert.True(t,
+		list.HandleEvent(tcell.NewEventKey(tcell.KeyRune, labels["baz"], 0)))
+}
+
+// gridView is a View that records the text drawn on it.
+type gridView struct {
+	w, h  int
+	cells [][]string
+}
+
+func newGridView(w, h int) *gridView {
+	cells := make([][]string, h)
+	for y := range cells {
+		cells[y] = make([]string, w)
+	}
+	return &gridView{w: w, h: h, cells: cells}
+}
+
+func (v *gridView) Size() (int, int) { return v.w, v.h }
+
+func (v *gridView) Put(x, y int, str string, _ tcell.Style) (string, int) {
+	v.cells[y][x] = str
+	return "", 1
+}
+
+// Rows returns the text on each row of the view without trailing spaces.
+func (v *gridView) Rows() []string {
+	rows := make([]string, v.h)
+	for y, row := range v.cells {
+		rows[y] = strings.TrimRight(strings.Join(row, ""), " ")
+	}
+	return rows
+}
@@ -20,12 +20,35 @@ type Match struct {
 
 	// Range identifies the matched area.
 	Range Range
+
+	// Wrapped identifies the rest of the matched area, in order,
+	// if the match was wrapped across rows of the text.
+	// The matched text is the text in Range followed by the text in each
+	// of these.
+	Wrapped []Range
 }
 
 func (m Match) String() string {
+	if len(m.Wrapped) > 0 {
+		return fmt.Sprintf("(%q) %v %v", m.Matcher, m.Range, m.Wrapped)
+	}
 	return fmt.Sprintf("(%q) %v", m.Matcher, m.Range)
 }
 
+// TextIn reports the text matched by this match in the given text.
+func (m Match) TextIn(text string) string {
+	if len(m.Wrapped) == 0 {
+		return text[m.Range.Start:m.Range.End]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(text[m.Range.Start:m.Range.End])
+	for _, r := range m.Wrapped {
+		sb.WriteString(text[r.Start:r.End])
+	}
+	return sb.String()
+}
+
 // Range specifies a range of offsets in a text, referring to the [start:end)
 // subslice of the text.
 type Range struct{ Start, End int }
@@ -62,11 +85,18 @@ type Selection struct {
 	// Invariant: this list contains at least one item.
 	Matchers []string
 
-	// Shift reports whether shift was pressed when this value was
+	// Action is the name of the action requested for this selection,
+	// or empty for the default action.
+	//
+	// This is ShiftAction if shift was pressed when this value was
 	// selected.
-	Shift bool
+	Action string
 }
 
+// ShiftAction is the name of the action requested by typing a label with
+// Shift held down.
+const ShiftAction = "shift"
+
 // Handler handles events from the widget.
 type Handler interface {
 	// HandleSelection reports the hint label and the corresponding matched
@@ -87,6 +117,21 @@ type WidgetConfig struct {
 	// Alphabet we'll use to generate labels.
 	HintAlphabet []rune
 
+	// PreviousLabels maps text to the label it was assigned in a prior
+	// run. If text is still present, the widget tries to assign it the
+	// same label.
+	PreviousLabels map[string]string
+
+	// LabelStrategy specifies how labels are generated.
+	// Defaults to HuffmanLabels.
+	LabelStrategy LabelStrategy
+
+	// HintWeight reports the relative weight of text matched on the
+	// screen. Text with a higher weight is assigned shorter labels.
+	//
+	// Defaults to the number of times the text appears on the screen.
+	HintWeight func(text string, matches []Match) int
+
 	// Handler handles events from the widget. This includes hint
 	// selection.
 	Handler Handler
@@ -94,8 +139,18 @@ type WidgetConfig struct {
 	// Style configures the look of the widget.
 	Style Style
 
+	// AltAction is the name of the action requested by typing a label
+	// with Alt held down, if any.
+	AltAction string
+
+	// LeaderActions maps keys to the names of actions requested by
+	// pressing that key right before typing a label.
+	//
+	// Leader keys must not be part of HintAlphabet.
+	LeaderActions map[string]string
+
 	// Internal override for generateHints.
-	generateHints func([]rune, string, []Match) []hint
+	generateHints func(hintOptions, string, []Match) []hint
 }
 
 // Widget is the main fastcopy widget. It displays some fixed text with zero or
@@ -109,11 +164,19 @@ type Widget struct {
 	hints        []hint
 	hintsByLabel map[string]int // label -> hints[i]
 
+	altAction     string
+	leaderActions map[string]string // leader key -> action
+
+	// Hints laid out as a list for ListWidget.
+	listw       *ui.AnnotatedText
+	listMatches [][]Match // hints[i] -> matches in listw
+
 	// Mutable attributes:
 
 	mu          sync.RWMutex
 	input       string // text input so far
-	shiftDown   bool   // whether shift was pressed
+	leader      string // action requested with a leader key, if any
+	action      string // action requested for the selection
 	multiSelect bool   // whether in multi select mode
 }
 
@@ -124,13 +187,19 @@ func (cfg *WidgetConfig) Build() *Widget {
 		generateHints = cfg.generateHints
 	}
 
-	hints := generateHints(cfg.HintAlphabet, cfg.Text, cfg.Matches)
+	hints := generateHints(hintOptions{
+		Alphabet: cfg.HintAlphabet,
+		Label:    cfg.LabelStrategy.labelFunc(),
+		Weigh:    cfg.HintWeight,
+	}, cfg.Text, cfg.Matches)
+	reuseLabels(hints, cfg.PreviousLabels)
 	byLabel := make(map[string]int, len(hints))
 
 	for i, hint := range hints {
 		byLabel[hint.Label] = i
 	}
 
+	listText, listMatches := buildList(cfg.Text, hints)
 	w := &Widget{
 		textw: &ui.AnnotatedText{
 			Text:  cfg.Text,
@@ -140,6 +209,13 @@ func (cfg *WidgetConfig) Build() *Widget {
 		handler:      cfg.Handler,
 		hints:        hints,
 		hintsByLabel: byLabel,
+		listw: &ui.AnnotatedText{
+			Text:  listText,
+			Style: cfg.Style.Normal,
+		},
+		listMatches:   listMatches,
+		altAction:     cfg.AltAction,
+		leaderActions: cfg.LeaderActions,
 	}
 	w.annotateText()
 	return w
@@ -150,6 +226,12 @@ func (w *Widget) Draw(view ui.View) {
 	w.textw.Draw(view)
 }
 
+// Height reports the number of rows needed to draw the widget in full on a
+// view of the given width.
+func (w *Widget) Height(width int) int {
+	return w.textw.Height(width)
+}
+
 // Input reports the text input into the label so far to partially select a
 // label.
 func (w *Widget) Input() string {
@@ -158,6 +240,19 @@ func (w *Widget) Input() string {
 	return w.input
 }
 
+// Labels reports the labels assigned to text on the widget, mapping the text
+// to its label.
+func (w *Widget) Labels() map[string]string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	labels := make(map[string]string, len(w.hints))
+	for _, h := range w.hints {
+		labels[h.Text] = h.Label
+	}
+	return labels
+}
+
 // HandleEvent handles input for the widget. This only responds to text input,
 // and delegates everything else to the caller.
 func (w *Widget) HandleEvent(ev tcell.Event) (handled bool) {
@@ -173,6 +268,9 @@ func (w *Widget) HandleEvent(ev tcell.Event) (handled bool) {
 		if n := len(w.input); n > 0 {
 			w.input = w.input[:n-1]
 			defer w.inputChanged()
+		} else {
+			// Backspace with no input forgets the leader key.
+			w.leader = ""
 		}
 		w.mu.Unlock()
 
@@ -194,48 +292,75 @@ func (w *Widget) HandleEvent(ev tcell.Event) (handled bool) {
 		}
 
 	case tcell.KeyRune:
-		var input string
-		input, w.shiftDown, handled = normalizeKeyInput(ek)
-		if handled {
-			w.mu.Lock()
-			w.input += input
-			defer w.inputChanged()
+		var (
+			input string
+			mods  tcell.ModMask
+		)
+		input, mods, handled = normalizeKeyInput(ek)
+		if !handled {
+			break
+		}
+
+		w.mu.Lock()
+		if action, ok := w.leaderActions[input]; ok && len(w.input) == 0 {
+			// Leader keys are only recognized before a label.
+			w.leader = action
 			w.mu.Unlock()
+			break
 		}
+		w.action = w.requestedAction(mods)
+		w.input += input
+		defer w.inputChanged()
+		w.mu.Unlock()
 	}
 
 	return handled
 }
 
+// requestedAction reports the name of the action requested by typing a
+// label with the given modifiers. The caller must hold the lock.
+func (w *Widget) requestedAction(mods tcell.ModMask) string {
+	switch {
+	case mods&tcell.ModShift != 0:
+		return ShiftAction
+	case mods&tcell.ModAlt != 0 && len(w.altAction) > 0:
+		return w.altAction
+	default:
+		return w.leader
+	}
+}
+
 // normalizeKeyInput converts a rune key event into widget input.
-// It returns the normalized label input, whether the selection should be
-// treated as shifted, and whether the event should be handled at all.
-func normalizeKeyInput(ek *tcell.EventKey) (_ string, _ bool, ok bool) {
+// It returns the normalized label input, the modifiers held down for it,
+// and whether the event should be handled at all.
+//
+// Uppercase input is reported as lowercase input with ModShift.
+func normalizeKeyInput(ek *tcell.EventKey) (_ string, _ tcell.ModMask, ok bool) {
 	input := ek.Str()
 	if input == "" {
-		return "", false, false
+		return "", tcell.ModNone, false
 	}
 
 	// Accept only a single grapheme cluster so multi-rune key payloads
 	// cannot be split across multiple hint-label steps.
 	g := uniseg.NewGraphemes(input)
 	if !g.Next() || g.Str() != input {
-		return "", false, false
+		return "", tcell.ModNone, false
 	}
 
 	runes := g.Runes()
 	if len(runes) != 1 {
-		return "", false, false
+		return "", tcell.ModNone, false
 	}
 
 	r := runes[0]
-	shift := ek.Modifiers()&tcell.ModShift != 0
+	mods := ek.Modifiers()
 	if unicode.IsUpper(r) {
 		r = unicode.ToLower(r)
-		shift = true
+		mods |= tcell.ModShift
 	}
 
-	return string(r), shift, true
+	return string(r), mods, true
 }
 
 func (w *Widget) inputChanged() {
@@ -253,6 +378,7 @@ func (w *Widget) inputChanged() {
 		// Clear the input to allow for more selections
 		// if we're in multi-select mode.
 		w.input = ""
+		w.leader = ""
 	}
 	w.mu.Unlock()
 
@@ -299,8 +425,8 @@ func (w *Widget) handleSelection() {
 	}
 
 	sel := Selection{
-		Text:  text.String(),
-		Shift: w.shiftDown,
+		Text:   text.String(),
+		Action: w.action,
 	}
 	for m := range matchers {
 		sel.Matchers = append(sel.Matchers, m)
@@ -314,8 +440,8 @@ func (w *Widget) annotateText() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	var anns []ui.TextAnnotation
-	for _, hint := range w.hints {
+	var anns, listAnns []ui.TextAnnotation
+	for i, hint := range w.hints {
 		input := w.input
 		style := AnnotationStyle{
 			Match:      w.style.Match,
@@ -336,7 +462,11 @@ func (w *Widget) annotateText() {
 		}
 
 		anns = append(anns, hint.Annotations(input, style)...)
+
+		hint.Matches = w.listMatches[i]
+		listAnns = append(listAnns, hint.Annotations(input, style)...)
 	}
 
 	w.textw.SetAnnotations(anns...)
+	w.listw.SetAnnotations(listAnns...)
 }
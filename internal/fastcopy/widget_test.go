@@ -1,6 +1,7 @@
 package fastcopy
 
 import (
+	"strings"
 	"testing"
 
 	tcell "github.com/gdamore/tcell/v3"
@@ -51,6 +52,20 @@ func TestRange(t *testing.T) {
 	}
 }
 
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	text := "foo ba\nr baz"
+
+	m := Match{Matcher: "x", Range: Range{4, 6}}
+	assert.Equal(t, "ba", m.TextIn(text))
+	assert.Equal(t, `("x") [4, 6)`, m.String())
+
+	m.Wrapped = []Range{{7, 8}}
+	assert.Equal(t, "bar", m.TextIn(text))
+	assert.Equal(t, `("x") [4, 6) [[7, 8)]`, m.String())
+}
+
 func sampleStyle() Style {
 	return Style{
 		Normal:         tcell.StyleDefault,
@@ -77,20 +92,20 @@ func TestWidget(t *testing.T) {
 	w := (&WidgetConfig{
 		Text: "foo\nbar\nbaz\nqux",
 		Matches: []Match{
-			{"p", Range{0, 2}},   // (fo)
-			{"q", Range{5, 7}},   // (ar)
-			{"r", Range{9, 11}},  // (az)
-			{"s", Range{12, 14}}, // (qu)
+			{Matcher: "p", Range: Range{0, 2}},   // (fo)
+			{Matcher: "q", Range: Range{5, 7}},   // (ar)
+			{Matcher: "r", Range: Range{9, 11}},  // (az)
+			{Matcher: "s", Range: Range{12, 14}}, // (qu)
 		},
 		HintAlphabet: []rune("ab"),
 		Handler:      handler,
 		Style:        style,
-		generateHints: func([]rune, string, []Match) []hint {
+		generateHints: func(hintOptions, string, []Match) []hint {
 			return []hint{
-				{Label: "aa", Text: "fo", Matches: []Match{{"p", Range{0, 2}}}},   // (fo)
-				{Label: "bb", Text: "ar", Matches: []Match{{"q", Range{5, 7}}}},   // (ar)
-				{Label: "ba", Text: "az", Matches: []Match{{"r", Range{9, 11}}}},  // (az)
-				{Label: "ab", Text: "qu", Matches: []Match{{"p", Range{12, 14}}}}, // (qu)
+				{Label: "aa", Text: "fo", Matches: []Match{{Matcher: "p", Range: Range{0, 2}}}},   // (fo)
+				{Label: "bb", Text: "ar", Matches: []Match{{Matcher: "q", Range: Range{5, 7}}}},   // (ar)
+				{Label: "ba", Text: "az", Matches: []Match{{Matcher: "r", Range: Range{9, 11}}}},  // (az)
+				{Label: "ab", Text: "qu", Matches: []Match{{Matcher: "p", Range: Range{12, 14}}}}, // (qu)
 			}
 		},
 	}).Build()
@@ -132,7 +147,7 @@ func TestWidget(t *testing.T) {
 			HandleSelection(Selection{
 				Text:     "qu",
 				Matchers: []string{"p"},
-				Shift:    true,
+				Action:   ShiftAction,
 			})
 
 		assert.True(t,
@@ -189,3 +204,89 @@ func TestWidget(t *testing.T) {
 		assert.Equal(t, "a", w.Input())
 	})
 }
+
+func TestWidget_actions(t *testing.T) {
+	t.Parallel()
+
+	newWidget := func(t *testing.T) (*Widget, *MockHandler) {
+		mockCtrl := gomock.NewController(t)
+		handler := NewMockHandler(mockCtrl)
+		w := (&WidgetConfig{
+			Text:          "foo bar",
+			Matches:       []Match{{Matcher: "x", Range: Range{0, 3}}, {Matcher: "y", Range: Range{4, 7}}},
+			HintAlphabet:  []rune("ab"),
+			Handler:       handler,
+			Style:         sampleStyle(),
+			AltAction:     "open",
+			LeaderActions: map[string]string{",": "edit"},
+		}).Build()
+		return w, handler
+	}
+
+	key := func(str string, mods tcell.ModMask) *tcell.EventKey {
+		return tcell.NewEventKey(tcell.KeyRune, str, mods)
+	}
+
+	t.Run("alt", func(t *testing.T) {
+		t.Parallel()
+
+		w, handler := newWidget(t)
+		handler.EXPECT().
+			HandleSelection(Selection{Text: "foo", Matchers: []string{"x"}, Action: "open"})
+		assert.True(t, w.HandleEvent(key(w.Labels()["foo"], tcell.ModAlt)))
+	})
+
+	t.Run("leader", func(t *testing.T) {
+		t.Parallel()
+
+		w, handler := newWidget(t)
+		handler.EXPECT().
+			HandleSelection(Selection{Text: "bar", Matchers: []string{"y"}, Action: "edit"})
+		assert.True(t, w.HandleEvent(key(",", 0)))
+		assert.Empty(t, w.Input(), "leader must not be part of the input")
+		assert.True(t, w.HandleEvent(key(w.Labels()["bar"], 0)))
+	})
+
+	t.Run("shift overrides leader", func(t *testing.T) {
+		t.Parallel()
+
+		w, handler := newWidget(t)
+		handler.EXPECT().
+			HandleSelection(Selection{Text: "bar", Matchers: []string{"y"}, Action: ShiftAction})
+		assert.True(t, w.HandleEvent(key(",", 0)))
+		assert.True(t, w.HandleEvent(key(strings.ToUpper(w.Labels()["bar"]), 0)))
+	})
+
+	t.Run("backspace forgets leader", func(t *testing.T) {
+		t.Parallel()
+
+		w, handler := newWidget(t)
+		handler.EXPECT().
+			HandleSelection(Selection{Text: "foo", Matchers: []string{"x"}})
+		assert.True(t, w.HandleEvent(key(",", 0)))
+		assert.True(t, w.HandleEvent(tcell.NewEventKey(tcell.KeyBackspace, "", 0)))
+		assert.True(t, w.HandleEvent(key(w.Labels()["foo"], 0)))
+	})
+}
+
+func TestWidget_PreviousLabels(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	w := (&WidgetConfig{
+		Text: "foo bar",
+		Matches: []Match{
+			{Matcher: "x", Range: Range{0, 3}}, // foo
+			{Matcher: "x", Range: Range{4, 7}}, // bar
+		},
+		HintAlphabet:   []rune("ab"),
+		Handler:        NewMockHandler(mockCtrl),
+		Style:          sampleStyle(),
+		PreviousLabels: map[string]string{"foo": "b"},
+	}).Build()
+
+	assert.Equal(t, map[string]string{
+		"foo": "b",
+		"bar": "a",
+	}, w.Labels())
+}
@@ -0,0 +1,175 @@
+// Package history records the text selected with tmux-fastcopy
+// so that the kinds of text selected most often can be given shorter labels.
+package history
+
+import (
+	"sort"
+
+	"github.com/abhinav/tmux-fastcopy/internal/statefile"
+)
+
+// _maxTexts is the maximum number of distinct texts retained by the
+// history. Texts selected least often are dropped first, with counts
+// discounted by how long ago the text was last selected: a count weighs
+// half as much once _maxTexts more selections have been recorded since.
+const _maxTexts = 256
+
+// History is a record of prior selections.
+//
+// The zero value is an empty history.
+type History struct {
+	// Total number of selections recorded.
+	Total int `json:"total"`
+
+	// Regexes maps regex names to the number of selections
+	// that they matched.
+	Regexes map[string]int `json:"regexes,omitempty"`
+
+	// Texts maps selected text to the number of times it was selected.
+	Texts map[string]int `json:"texts,omitempty"`
+
+	// Last maps selected text to the value of Total
+	// when it was last selected.
+	Last map[string]int `json:"last,omitempty"`
+}
+
+// Load loads the history from the given file.
+// If the file does not exist, an empty history is returned.
+func Load(path string) (*History, error) {
+	var h History
+	if _, err := statefile.Read(path, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Save writes the history to the given file,
+// dropping the least selected texts if it has grown too large.
+// Texts that haven't been selected in a while are dropped sooner.
+func (h *History) Save(path string) error {
+	h.prune(_maxTexts)
+	return statefile.Write(path, h)
+}
+
+// Record records a selection of the given text,
+// matched by the given regexes.
+func (h *History) Record(text string, regexes []string) {
+	if h.Regexes == nil {
+		h.Regexes = make(map[string]int)
+	}
+	if h.Texts == nil {
+		h.Texts = make(map[string]int)
+	}
+	if h.Last == nil {
+		h.Last = make(map[string]int)
+	}
+
+	h.Total++
+	h.Texts[text]++
+	h.Last[text] = h.Total
+	for _, name := range regexes {
+		h.Regexes[name]++
+	}
+}
+
+// Weight reports a weight for text matched by the given regexes, based on
+// how often such text was selected in the past. Weights are always positive,
+// and text that was never selected has a weight of 10.
+//
+// Weights are relative: text of a kind selected in nearly all recorded
+// selections weighs about ten times as much as text of a kind that was never
+// selected. Text that was selected before weighs more still.
+func (h *History) Weight(text string, regexes []string) int {
+	weight := 10
+	if h.Total == 0 {
+		return weight
+	}
+
+	// Text matched by multiple regexes gets the benefit of the most
+	// popular one.
+	var best int
+	for _, name := range regexes {
+		best = max(best, h.Regexes[name])
+	}
+	weight += 90 * best / h.Total
+
+	weight += 10 * min(h.Texts[text], 9)
+	return weight
+}
+
+// Count is the number of times an item was selected.
+type Count struct {
+	Value string
+	Count int
+}
+
+// TopRegexes reports the regex names in the history,
+// ordered by the number of selections they matched.
+func (h *History) TopRegexes() []Count {
+	return sortCounts(h.Regexes)
+}
+
+// TopTexts reports the texts in the history,
+// ordered by the number of times they were selected.
+func (h *History) TopTexts() []Count {
+	return sortCounts(h.Texts)
+}
+
+func (h *History) prune(limit int) {
+	if len(h.Texts) <= limit {
+		return
+	}
+
+	texts := make([]string, 0, len(h.Texts))
+	for text := range h.Texts {
+		texts = append(texts, text)
+	}
+
+	// Rank texts by count / (limit + age) so that old counts decay and
+	// new texts get a chance to build up theirs. The comparison is
+	// cross-multiplied to avoid division.
+	sort.Slice(texts, func(i, j int) bool {
+		a, b := texts[i], texts[j]
+		ageA, ageB := h.age(a), h.age(b)
+		scoreA := h.Texts[a] * (limit + ageB)
+		scoreB := h.Texts[b] * (limit + ageA)
+		if scoreA != scoreB {
+			return scoreA > scoreB
+		}
+		if ageA != ageB {
+			return ageA < ageB
+		}
+		return a < b
+	})
+
+	for _, text := range texts[limit:] {
+		delete(h.Texts, text)
+		delete(h.Last, text)
+	}
+}
+
+// age reports the number of selections recorded
+// since the given text was last selected.
+func (h *History) age(text string) int {
+	last, ok := h.Last[text]
+	if !ok {
+		// Recorded before we tracked this.
+		// Treat it as the oldest possible.
+		return h.Total
+	}
+	return h.Total - last
+}
+
+func sortCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for v, n := range m {
+		counts = append(counts, Count{Value: v, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	return counts
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h, err := Load(path)
+	require.NoError(t, err)
+	assert.Zero(t, h.Total, "history must start empty")
+
+	h.Record("016ca97", []string{"gitsha"})
+	h.Record("016ca97", []string{"gitsha"})
+	h.Record("/usr/bin", []string{"path"})
+	h.Record("1234", []string{"gitsha", "int"})
+	require.NoError(t, h.Save(path))
+
+	h, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, h.Total)
+	assert.Equal(t, []Count{
+		{"gitsha", 3},
+		{"int", 1},
+		{"path", 1},
+	}, h.TopRegexes())
+	assert.Equal(t, []Count{
+		{"016ca97", 2},
+		{"/usr/bin", 1},
+		{"1234", 1},
+	}, h.TopTexts())
+}
+
+func TestHistoryWeight(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var h History
+		assert.Equal(t, 10, h.Weight("foo", []string{"gitsha"}))
+	})
+
+	t.Run("regexes", func(t *testing.T) {
+		t.Parallel()
+
+		var h History
+		for i := 0; i < 9; i++ {
+			h.Record(fmt.Sprint("sha", i), []string{"gitsha"})
+		}
+		h.Record("1234", []string{"int"})
+
+		sha := h.Weight("new sha", []string{"gitsha"})
+		num := h.Weight("5678", []string{"int"})
+		path := h.Weight("/foo/bar", []string{"path"})
+		assert.Greater(t, sha, num)
+		assert.Greater(t, num, path)
+		assert.Equal(t, 10, path)
+
+		assert.Equal(t, sha, h.Weight("new sha", []string{"int", "gitsha"}),
+			"must use most popular regex")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+
+		var h History
+		h.Record("foo", []string{"x"})
+		h.Record("bar", []string{"x"})
+		h.Record("bar", []string{"x"})
+
+		assert.Greater(t,
+			h.Weight("bar", []string{"x"}),
+			h.Weight("foo", []string{"x"}))
+		assert.Greater(t,
+			h.Weight("foo", []string{"x"}),
+			h.Weight("baz", []string{"x"}))
+	})
+}
+
+func TestHistoryPrune(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	var h History
+	h.Record("popular", []string{"x"})
+	h.Record("popular", []string{"x"})
+	for i := 0; i < _maxTexts; i++ {
+		h.Record(fmt.Sprint(i), []string{"x"})
+	}
+	require.NoError(t, h.Save(path))
+
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, got.Texts, _maxTexts)
+	assert.Equal(t, 2, got.Texts["popular"])
+	assert.Equal(t, _maxTexts+2, got.Regexes["x"], "regexes must not be pruned")
+}
+
+func TestHistoryPrune_newText(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h := new(History)
+	for i := 0; i < _maxTexts; i++ {
+		h.Record(fmt.Sprint("old", i), []string{"x"})
+		h.Record(fmt.Sprint("old", i), []string{"x"})
+	}
+	require.NoError(t, h.Save(path))
+
+	// Every new text must survive the save that follows it
+	// even though the history is full of texts selected more often.
+	for i := 0; i < _maxTexts; i++ {
+		text := fmt.Sprint("new", i)
+		h.Record(text, []string{"x"})
+		require.NoError(t, h.Save(path))
+
+		got, err := Load(path)
+		require.NoError(t, err)
+		require.Len(t, got.Texts, _maxTexts)
+		require.Equal(t, 1, got.Texts[text], "text %q must be kept", text)
+		h = got
+	}
+
+	// Selecting one again must build up its count.
+	text := fmt.Sprint("new", _maxTexts-1)
+	h.Record(text, []string{"x"})
+	require.NoError(t, h.Save(path))
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Texts[text])
+}
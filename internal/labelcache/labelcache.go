@@ -0,0 +1,83 @@
+// Package labelcache remembers the labels assigned to text between
+// invocations of tmux-fastcopy so that the same text can be assigned the
+// same label again.
+package labelcache
+
+import (
+	"sort"
+	"time"
+
+	"github.com/abhinav/tmux-fastcopy/internal/statefile"
+)
+
+// _maxEntries is the maximum number of keys retained by the cache.
+// The least recently updated keys are dropped first.
+const _maxEntries = 32
+
+// Cache is a collection of label assignments keyed by an arbitrary string
+// (for example, a pane ID).
+//
+// The zero value is an empty cache.
+type Cache struct {
+	entries map[string]*entry
+}
+
+type entry struct {
+	// Labels maps text to the label it was assigned.
+	Labels map[string]string `json:"labels"`
+
+	// Updated is the last time this entry was changed.
+	Updated time.Time `json:"updated"`
+}
+
+// Load loads a cache from the given file. If the file does not exist, an
+// empty cache is returned.
+func Load(path string) (*Cache, error) {
+	var c Cache
+	if _, err := statefile.Read(path, &c.entries); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes the cache to the given file, dropping the oldest entries if the
+// cache has grown too large.
+func (c *Cache) Save(path string) error {
+	c.prune(_maxEntries)
+	return statefile.Write(path, c.entries)
+}
+
+// Get returns the labels recorded for the given key, or nil if the key is
+// unknown.
+func (c *Cache) Get(key string) map[string]string {
+	if e, ok := c.entries[key]; ok {
+		return e.Labels
+	}
+	return nil
+}
+
+// Put records the labels for the given key, replacing prior labels for it.
+func (c *Cache) Put(key string, labels map[string]string, now time.Time) {
+	if c.entries == nil {
+		c.entries = make(map[string]*entry)
+	}
+	c.entries[key] = &entry{Labels: labels, Updated: now}
+}
+
+func (c *Cache) prune(limit int) {
+	if len(c.entries) <= limit {
+		return
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].Updated.After(c.entries[keys[j]].Updated)
+	})
+
+	for _, k := range keys[limit:] {
+		delete(c.entries, k)
+	}
+}
@@ -0,0 +1,53 @@
+package labelcache
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "labels.json")
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, c.Get("%1"), "cache must start empty")
+
+	now := time.Date(2021, 8, 14, 12, 34, 0, 0, time.UTC)
+	c.Put("%1", map[string]string{"foo": "a"}, now)
+	c.Put("%2", map[string]string{"bar": "b"}, now)
+	c.Put("%1", map[string]string{"baz": "c"}, now)
+	require.NoError(t, c.Save(path))
+
+	c, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"baz": "c"}, c.Get("%1"))
+	assert.Equal(t, map[string]string{"bar": "b"}, c.Get("%2"))
+	assert.Nil(t, c.Get("%3"))
+}
+
+func TestCache_prune(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "labels.json")
+	start := time.Date(2021, 8, 14, 12, 34, 0, 0, time.UTC)
+
+	var c Cache
+	for i := 0; i < _maxEntries+2; i++ {
+		c.Put(fmt.Sprint(i), map[string]string{"foo": "a"}, start.Add(time.Duration(i)*time.Minute))
+	}
+	require.NoError(t, c.Save(path))
+
+	got, err := Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, got.Get("0"), "oldest entry must be dropped")
+	assert.Nil(t, got.Get("1"), "second oldest entry must be dropped")
+	assert.NotNil(t, got.Get("2"))
+	assert.NotNil(t, got.Get(fmt.Sprint(_maxEntries+1)))
+}
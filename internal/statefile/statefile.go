@@ -0,0 +1,77 @@
+// Package statefile reads and writes small JSON files that persist state
+// between invocations of tmux-fastcopy.
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/multierr"
+)
+
+// Read decodes the JSON file at path into v.
+//
+// It reports false with no error if the file does not exist,
+// leaving v unchanged.
+func Read(path string, v interface{}) (ok bool, err error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := json.Unmarshal(bs, v); err != nil {
+		return false, fmt.Errorf("decode %q: %w", path, err)
+	}
+	return true, nil
+}
+
+// Write encodes v as JSON into the file at path, creating parent directories
+// if necessary.
+//
+// The file is replaced atomically so that concurrent readers never see a
+// partially written file.
+func Write(path string, v interface{}) (err error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(bs)
+	err = multierr.Append(err, f.Close())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Remove deletes the file at path. It does not fail if the file does not
+// exist.
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package statefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWrite(t *testing.T) {
+	t.Parallel()
+
+	type state struct {
+		Items map[string]int `json:"items"`
+	}
+
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	var got state
+	ok, err := Read(path, &got)
+	require.NoError(t, err)
+	assert.False(t, ok, "file must not exist yet")
+
+	give := state{Items: map[string]int{"foo": 1, "bar": 2}}
+	require.NoError(t, Write(path, &give))
+
+	ok, err = Read(path, &got)
+	require.NoError(t, err)
+	assert.True(t, ok, "file must exist")
+	assert.Equal(t, give, got)
+
+	// No temporary files are left behind.
+	ents, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, ents, 1)
+
+	require.NoError(t, Remove(path))
+	require.NoError(t, Remove(path), "remove must be idempotent")
+
+	ok, err = Read(path, &got)
+	require.NoError(t, err)
+	assert.False(t, ok, "file must not exist after removal")
+}
+
+func TestRead_invalid(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	var v map[string]int
+	_, err := Read(path, &v)
+	assert.ErrorContains(t, err, "decode")
+}
@@ -0,0 +1,299 @@
+package tmux
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// This file builds the arguments for tmux commands.
+// These are shared between the drivers.
+
+// socketArgs builds the arguments that select the tmux server to talk to.
+// These go before the command.
+func socketArgs(name, path string) []string {
+	var args []string
+	if len(name) > 0 {
+		args = append(args, "-L", name)
+	}
+	if len(path) > 0 {
+		args = append(args, "-S", path)
+	}
+	return args
+}
+
+// newSessionArgs builds arguments for new-session. env is the path to the
+// env(1) command.
+func newSessionArgs(env string, req NewSessionRequest) ([]string, error) {
+	args := []string{"new-session"}
+	if n := req.Name; len(n) > 0 {
+		args = append(args, "-s", n)
+	}
+	if fmt := req.Format; len(fmt) > 0 {
+		args = append(args, "-P", "-F", fmt)
+	}
+	if w := req.Width; w > 0 {
+		args = append(args, "-x", strconv.Itoa(w))
+	}
+	if h := req.Height; h > 0 {
+		args = append(args, "-y", strconv.Itoa(h))
+	}
+	if req.Detached {
+		args = append(args, "-d")
+	}
+
+	// We could use the -e flg to set the environment variables, but that
+	// was added in tmux 3.2. Instead, use,
+	//
+	//   /usr/bin/env K1=V1 K2=V2 cmd "$1" "$2" ...
+	if len(req.Env) > 0 {
+		if len(req.Command) == 0 {
+			return nil, errors.New("env can be set only if command is set")
+		}
+		setenv := make([]string, len(req.Env)+1)
+		setenv[0] = env
+		copy(setenv[1:], req.Env)
+		args = append(args, setenv...)
+	}
+
+	return append(args, req.Command...), nil
+}
+
+func capturePaneArgs(req CapturePaneRequest) []string {
+	args := []string{"capture-pane", "-p"}
+	if len(req.Pane) > 0 {
+		args = append(args, "-t", req.Pane)
+	}
+	if s := req.StartLine; s != 0 {
+		args = append(args, "-S", strconv.Itoa(s))
+	}
+	if e := req.EndLine; e != 0 {
+		args = append(args, "-E", strconv.Itoa(e))
+	}
+	if req.JoinLines {
+		args = append(args, "-J")
+	}
+	if req.TrimTrailing {
+		args = append(args, "-T")
+	}
+	return args
+}
+
+func setOptionArgs(req SetOptionRequest) []string {
+	args := []string{"set-option"}
+	if req.Global {
+		args = append(args, "-g")
+	}
+	return append(args, req.Name, req.Value)
+}
+
+// _formatTab stands in for tabs in formats for display-message and
+// list-panes. tmux replaces
+// control characters like tabs with '_' in output sent to control clients
+// and to clients that it doesn't think support UTF-8 (e.g. when not run from
+// inside tmux), so we put a tab back wherever this appears in the output
+// with formatOutput.
+const _formatTab = "<~fastcopy-tab~>"
+
+func displayMessageArgs(req DisplayMessageRequest) []string {
+	args := []string{"display-message"}
+	msg := req.Message
+	if !req.StatusLine {
+		args = append(args, "-p")
+		msg = formatArg(msg)
+	}
+	if len(req.Pane) > 0 {
+		args = append(args, "-t", req.Pane)
+	}
+	return append(args, msg)
+}
+
+// formatArg replaces tabs in a format with _formatTab.
+func formatArg(format string) string {
+	return strings.ReplaceAll(format, "\t", _formatTab)
+}
+
+// formatOutput restores tabs in the output of a command that printed a
+// format built with formatArg.
+func formatOutput(out []byte) []byte {
+	return []byte(strings.ReplaceAll(string(out), _formatTab, "\t"))
+}
+
+func listPanesArgs(req ListPanesRequest) []string {
+	args := []string{"list-panes"}
+	switch {
+	case req.All:
+		args = append(args, "-a")
+	case req.Session:
+		args = append(args, "-s")
+		fallthrough
+	default:
+		if len(req.Window) > 0 {
+			args = append(args, "-t", req.Window)
+		}
+	}
+	if len(req.Format) > 0 {
+		args = append(args, "-F", formatArg(req.Format))
+	}
+	return args
+}
+
+func listBuffersArgs(req ListBuffersRequest) []string {
+	args := []string{"list-buffers"}
+	if len(req.Format) > 0 {
+		args = append(args, "-F", formatArg(req.Format))
+	}
+	return args
+}
+
+func listClientsArgs(req ListClientsRequest) []string {
+	args := []string{"list-clients"}
+	if len(req.Session) > 0 {
+		args = append(args, "-t", req.Session)
+	}
+	if len(req.Format) > 0 {
+		args = append(args, "-F", formatArg(req.Format))
+	}
+	return args
+}
+
+func showBufferArgs(req ShowBufferRequest) []string {
+	args := []string{"show-buffer"}
+	if len(req.Name) > 0 {
+		args = append(args, "-b", req.Name)
+	}
+	return args
+}
+
+func setBufferArgs(req SetBufferRequest) []string {
+	args := []string{"set-buffer"}
+	if req.Append {
+		args = append(args, "-a")
+	}
+	if req.Clipboard {
+		args = append(args, "-w")
+	}
+	if len(req.Name) > 0 {
+		args = append(args, "-b", req.Name)
+	}
+	return append(args, "--", req.Data)
+}
+
+func sendKeysArgs(req SendKeysRequest) []string {
+	args := []string{"send-keys"}
+	if len(req.Pane) > 0 {
+		args = append(args, "-t", req.Pane)
+	}
+	if req.Literal {
+		args = append(args, "-l")
+	}
+	args = append(args, "--")
+	return append(args, req.Keys...)
+}
+
+func swapPaneArgs(req SwapPaneRequest) []string {
+	args := []string{"swap-pane", "-t", req.Destination}
+	if s := req.Source; len(s) > 0 {
+		args = append(args, "-s", s)
+	}
+	if req.KeepZoom {
+		args = append(args, "-Z")
+	}
+	return args
+}
+
+// displayPopupArgs builds arguments for display-popup. env is the path to
+// the env(1) command.
+func displayPopupArgs(env string, req DisplayPopupRequest) ([]string, error) {
+	if len(req.Command) == 0 {
+		return nil, errors.New("popup command must be set")
+	}
+
+	args := []string{"display-popup"}
+	if req.CloseOnExit {
+		args = append(args, "-E")
+	}
+	if req.NoBorder {
+		args = append(args, "-B")
+	}
+	if p := req.Pane; len(p) > 0 {
+		args = append(args, "-t", p)
+	}
+	if x := req.X; len(x) > 0 {
+		args = append(args, "-x", x)
+	}
+	if y := req.Y; len(y) > 0 {
+		args = append(args, "-y", y)
+	}
+	if w := req.Width; w > 0 {
+		args = append(args, "-w", strconv.Itoa(w))
+	}
+	if h := req.Height; h > 0 {
+		args = append(args, "-h", strconv.Itoa(h))
+	}
+
+	// Older versions of display-popup accept only a single shell command
+	// and don't support -e, so build a shell command that sets the
+	// environment with env(1) instead.
+	command := req.Command
+	if len(req.Env) > 0 {
+		command = append(append([]string{env}, req.Env...), command...)
+	}
+	return append(args, shellQuote(command)), nil
+}
+
+// shellQuote joins the given arguments into a single shell command,
+// quoting them so that the shell passes them to the command as-is.
+//
+// tmux's own command parser understands this quoting too.
+func shellQuote(args []string) string {
+	var sb strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte('\'')
+		sb.WriteString(strings.ReplaceAll(arg, "'", `'\''`))
+		sb.WriteByte('\'')
+	}
+	return sb.String()
+}
+
+func resizePaneArgs(req ResizePaneRequest) []string {
+	args := []string{"resize-pane", "-t", req.Target}
+	if req.ToggleZoom {
+		args = append(args, "-Z")
+	}
+	return args
+}
+
+func resizeWindowArgs(req ResizeWindowRequest) []string {
+	args := []string{"resize-window"}
+	if w := req.Window; len(w) > 0 {
+		args = append(args, "-t", w)
+	}
+
+	if w := req.Width; w > 0 {
+		args = append(args, "-x", strconv.Itoa(w))
+	}
+	if h := req.Height; h > 0 {
+		args = append(args, "-y", strconv.Itoa(h))
+	}
+	return args
+}
+
+func waitForArgs(sig string) []string {
+	return []string{"wait-for", sig}
+}
+
+func sendSignalArgs(sig string) []string {
+	return []string{"wait-for", "-S", sig}
+}
+
+func showOptionsArgs(req ShowOptionsRequest) []string {
+	args := []string{"show-options"}
+	if req.Global {
+		args = append(args, "-g")
+	}
+	return args
+}
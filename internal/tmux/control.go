@@ -0,0 +1,521 @@
+package tmux
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+)
+
+// ControlDriver is a Driver implementation that talks to tmux in control
+// mode (tmux -C) over a single connection, instead of starting a new tmux
+// process for every command like ShellDriver.
+//
+// The connection is opened when the first command is run. Close it with
+// Close when the driver is no longer needed.
+//
+// Commands are run one at a time. Signals are waited for and sent with a
+// separate tmux process because tmux doesn't block control clients on
+// wait-for.
+type ControlDriver struct {
+	// Path to the tmux executable. Defaults to "tmux".
+	Path string
+
+	// Path to the env command. Defaults to /usr/bin/env.
+	Env string
+
+	// Name or path of the socket of the tmux server to talk to, if not
+	// the default one. These are the -L and -S flags of tmux.
+	SocketName string
+	SocketPath string
+
+	// Session to attach the control client to. This may be anything that
+	// identifies a session, including a pane ID like "%42". Commands that
+	// don't specify a target run against this session.
+	//
+	// Defaults to the most recently used session.
+	Session string
+
+	log  *log.Logger
+	once sync.Once
+
+	// Commands that need a real client, like display-popup,
+	// are run through this instead.
+	shell ShellDriver
+
+	// Starts the control mode client. Tests will provide a different
+	// implementation.
+	dial func(path string, args ...string) (*controlConn, error)
+
+	connOnce sync.Once
+	conn     *controlConn
+	connErr  error
+
+	mu sync.Mutex // serializes commands
+}
+
+var _ Driver = (*ControlDriver)(nil)
+
+func (c *ControlDriver) init() {
+	c.once.Do(func() {
+		if c.log == nil {
+			c.log = log.Discard
+		}
+
+		if c.Path == "" {
+			c.Path = _defaultTmux
+		}
+
+		if c.dial == nil {
+			c.dial = dialControl
+		}
+
+		c.shell.Path = c.Path
+		c.shell.Env = c.Env
+		c.shell.SocketName = c.SocketName
+		c.shell.SocketPath = c.SocketPath
+		c.shell.log = c.log
+		c.shell.init()
+		c.Env = c.shell.Env
+	})
+}
+
+// SetLogger specifies the logger for the ControlDriver. By default, the
+// ControlDriver does not log anything.
+func (c *ControlDriver) SetLogger(log *log.Logger) {
+	c.log = log
+	c.shell.log = log
+}
+
+// Close closes the connection to tmux, if any,
+// and waits for the control client to exit.
+func (c *ControlDriver) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	conn := c.conn
+	c.conn = nil
+	c.connErr = errors.New("tmux control mode connection is closed")
+	return conn.Close()
+}
+
+// connect opens the connection to tmux on first use.
+func (c *ControlDriver) connect() error {
+	c.connOnce.Do(func() {
+		args := socketArgs(c.SocketName, c.SocketPath)
+		args = append(args, "-C", "attach-session")
+		if len(c.Session) > 0 {
+			args = append(args, "-t", c.Session)
+		}
+
+		c.log.Debugf("tmux control mode: %v %q", c.Path, args)
+		conn, err := c.dial(c.Path, args...)
+		if err != nil {
+			c.connErr = fmt.Errorf("start tmux control mode: %v", err)
+			return
+		}
+		go conn.readReplies(c.log)
+		c.conn = conn
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connErr
+}
+
+// command runs a tmux command over the connection and returns its output.
+func (c *ControlDriver) command(args ...string) ([]byte, error) {
+	line, err := controlCommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The connection may have been closed since we connected.
+	if c.conn == nil {
+		return nil, c.connErr
+	}
+
+	if _, err := io.WriteString(c.conn.stdin, line+"\n"); err != nil {
+		return nil, fmt.Errorf("send %v: %v", args[0], err)
+	}
+	return c.conn.reply(args[0])
+}
+
+// NewSession runs the tmux new-session command.
+func (c *ControlDriver) NewSession(req NewSessionRequest) ([]byte, error) {
+	c.init()
+
+	args, err := newSessionArgs(c.Env, req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log.Debugf("new session: %v", req)
+	return c.command(args...)
+}
+
+// CapturePane runs the capture-pane command and returns its output.
+func (c *ControlDriver) CapturePane(req CapturePaneRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("capture pane: %v", req)
+	return c.command(capturePaneArgs(req)...)
+}
+
+// SetOption runs the set-option command with the given parameters.
+func (c *ControlDriver) SetOption(req SetOptionRequest) error {
+	c.init()
+
+	c.log.Debugf("set-option: %v", req)
+	_, err := c.command(setOptionArgs(req)...)
+	return err
+}
+
+// DisplayMessage displays the given message in tmux and returns its output.
+//
+// Messages for the status line are shown with a separate tmux process
+// because the control client doesn't have a status line.
+func (c *ControlDriver) DisplayMessage(req DisplayMessageRequest) ([]byte, error) {
+	c.init()
+
+	if req.StatusLine {
+		return c.shell.DisplayMessage(req)
+	}
+
+	c.log.Debugf("display message: %v", req)
+	out, err := c.command(displayMessageArgs(req)...)
+	return formatOutput(out), err
+}
+
+// ListPanes runs the list-panes command and returns its output.
+func (c *ControlDriver) ListPanes(req ListPanesRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("list panes: %v", req)
+	out, err := c.command(listPanesArgs(req)...)
+	return formatOutput(out), err
+}
+
+// ListBuffers runs the list-buffers command and returns its output.
+func (c *ControlDriver) ListBuffers(req ListBuffersRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("list buffers: %v", req)
+	out, err := c.command(listBuffersArgs(req)...)
+	return formatOutput(out), err
+}
+
+// ListClients runs the list-clients command and returns its output.
+// This includes the control mode client if it's attached to the session.
+func (c *ControlDriver) ListClients(req ListClientsRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("list clients: %v", req)
+	out, err := c.command(listClientsArgs(req)...)
+	return formatOutput(out), err
+}
+
+// ShowBuffer runs the show-buffer command and returns the contents of the
+// buffer.
+func (c *ControlDriver) ShowBuffer(req ShowBufferRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("show buffer: %v", req)
+	return c.command(showBufferArgs(req)...)
+}
+
+// SetBuffer runs the set-buffer command.
+//
+// Control mode commands can't contain newlines, so buffers with multiple
+// lines are set with a separate tmux process.
+func (c *ControlDriver) SetBuffer(req SetBufferRequest) error {
+	c.init()
+
+	if strings.ContainsAny(req.Data, "\r\n") {
+		return c.shell.SetBuffer(req)
+	}
+
+	c.log.Debugf("set buffer: %v", req)
+	_, err := c.command(setBufferArgs(req)...)
+	return err
+}
+
+// SendKeys runs the send-keys command.
+//
+// Keys with newlines are sent with a separate tmux process because control
+// mode commands can't contain them.
+func (c *ControlDriver) SendKeys(req SendKeysRequest) error {
+	c.init()
+
+	for _, key := range req.Keys {
+		if strings.ContainsAny(key, "\r\n") {
+			return c.shell.SendKeys(req)
+		}
+	}
+
+	c.log.Debugf("send keys: %v", req)
+	_, err := c.command(sendKeysArgs(req)...)
+	return err
+}
+
+// SwapPane runs the swap-pane command.
+func (c *ControlDriver) SwapPane(req SwapPaneRequest) error {
+	c.init()
+
+	c.log.Debugf("swap pane: %v", req)
+	_, err := c.command(swapPaneArgs(req)...)
+	return err
+}
+
+// DisplayPopup runs the display-popup command.
+//
+// Popups are opened with a separate tmux process because they must be shown
+// on a real client, not the control client.
+func (c *ControlDriver) DisplayPopup(req DisplayPopupRequest) error {
+	c.init()
+	return c.shell.DisplayPopup(req)
+}
+
+// ResizePane runs the resize-pane command.
+func (c *ControlDriver) ResizePane(req ResizePaneRequest) error {
+	c.init()
+
+	c.log.Debugf("resize pane: %v", req)
+	_, err := c.command(resizePaneArgs(req)...)
+	return err
+}
+
+// ResizeWindow runs the resize-window command.
+func (c *ControlDriver) ResizeWindow(req ResizeWindowRequest) error {
+	c.init()
+
+	c.log.Debugf("resize window: %v", req)
+	_, err := c.command(resizeWindowArgs(req)...)
+	return err
+}
+
+// WaitForSignal runs the wait-for command.
+//
+// This uses a separate tmux process because wait-for returns right away
+// for control clients instead of waiting for the signal.
+func (c *ControlDriver) WaitForSignal(sig string) error {
+	c.init()
+	return c.shell.WaitForSignal(sig)
+}
+
+// SendSignal runs the wait-for -S command.
+//
+// This uses a separate tmux process to match WaitForSignal.
+func (c *ControlDriver) SendSignal(sig string) error {
+	c.init()
+	return c.shell.SendSignal(sig)
+}
+
+// ShowOptions runs the show-options command.
+func (c *ControlDriver) ShowOptions(req ShowOptionsRequest) ([]byte, error) {
+	c.init()
+
+	c.log.Debugf("show options: %v", req)
+	return c.command(showOptionsArgs(req)...)
+}
+
+// Version runs tmux -V and parses its output. Control mode has no
+// equivalent, so this uses a separate tmux process. The version is looked
+// up only once.
+func (c *ControlDriver) Version() (Version, error) {
+	c.init()
+	return c.shell.Version()
+}
+
+// controlCommand quotes the given arguments into a single line for tmux to
+// parse.
+func controlCommand(args []string) (string, error) {
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "\r\n") {
+			return "", fmt.Errorf("%v: arguments cannot contain newlines in control mode", args[0])
+		}
+	}
+	return shellQuote(args), nil
+}
+
+// controlConn is a connection to a tmux control mode client.
+type controlConn struct {
+	stdin  io.WriteCloser
+	stdout io.Reader
+
+	// Waits for the client to exit, if set.
+	wait func() error
+
+	// Replies to commands, in the order that the commands were sent.
+	// This is closed when the connection is closed.
+	replies chan controlReply
+}
+
+type controlReply struct {
+	Output []byte
+	Failed bool // reply ended with %error
+}
+
+func dialControl(path string, args ...string) (*controlConn, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	wait := func() error {
+		// tmux exits with a non-zero status if any of the commands
+		// failed. We've already reported those failures.
+		var exitErr *exec.ExitError
+		if err := cmd.Wait(); err != nil && !errors.As(err, &exitErr) {
+			return err
+		}
+		return nil
+	}
+	return newControlConn(stdin, stdout, wait), nil
+}
+
+func newControlConn(stdin io.WriteCloser, stdout io.Reader, wait func() error) *controlConn {
+	return &controlConn{
+		stdin:   stdin,
+		stdout:  stdout,
+		wait:    wait,
+		replies: make(chan controlReply, 1),
+	}
+}
+
+// Close closes the connection and waits for the client to exit.
+func (c *controlConn) Close() error {
+	err := c.stdin.Close()
+
+	// The client exits when its input is closed. Read everything it
+	// writes until then so that it doesn't block on a full pipe.
+	for range c.replies {
+	}
+
+	if c.wait != nil {
+		if waitErr := c.wait(); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return err
+}
+
+// reply waits for the reply to the next command and returns its output.
+func (c *controlConn) reply(name string) ([]byte, error) {
+	r, ok := <-c.replies
+	if !ok {
+		return nil, fmt.Errorf("%v: tmux control mode connection closed", name)
+	}
+	if r.Failed {
+		return nil, fmt.Errorf("%v: %s", name, strings.TrimSpace(string(r.Output)))
+	}
+	return r.Output, nil
+}
+
+// readReplies reads replies to commands from the control client until it
+// exits.
+//
+// Replies are framed like so, where GUARD is a timestamp, a command number,
+// and flags.
+//
+//	%begin GUARD
+//	output
+//	%end GUARD
+//
+// %error takes the place of %end if the command failed. Other lines that
+// start with '%' outside of replies are notifications, which we ignore.
+//
+// The flags are 1 only for commands that we sent. Replies to other commands,
+// like the attach-session that started the client, are not for us.
+func (c *controlConn) readReplies(logger *log.Logger) {
+	defer close(c.replies)
+
+	var (
+		inReply bool
+		guard   string
+		ours    bool
+		output  []byte
+	)
+
+	r := bufio.NewReader(c.stdout)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Errorf("read from tmux control mode: %v", err)
+			}
+			return
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if !inReply {
+			if g, ok := strings.CutPrefix(line, "%begin "); ok {
+				if flags, ok := parseGuard(g); ok {
+					inReply = true
+					guard = g
+					ours = flags&1 != 0
+					output = nil
+				}
+			}
+			continue
+		}
+
+		// Output may contain lines that look like %end, so the reply
+		// ends only on the same guard that it began with.
+		switch line {
+		case "%end " + guard, "%error " + guard:
+			inReply = false
+			failed := strings.HasPrefix(line, "%error ")
+			if ours {
+				c.replies <- controlReply{Output: output, Failed: failed}
+			} else if failed {
+				logger.Errorf("tmux control mode: %s", strings.TrimSpace(string(output)))
+			}
+		default:
+			output = append(output, line...)
+			output = append(output, '\n')
+		}
+	}
+}
+
+// parseGuard parses the guard for a reply: a timestamp, a command number,
+// and flags. It reports false if s is not a valid guard.
+func parseGuard(s string) (flags int64, ok bool) {
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return 0, false
+	}
+	for _, f := range fields {
+		n, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		flags = n // the last one is the flags
+	}
+	return flags, true
+}
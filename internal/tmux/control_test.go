@@ -0,0 +1,320 @@
+package tmux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhinav/tmux-fastcopy/internal/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeControl is a fake tmux control mode client. It replies to each
+// command it receives with the reply from the handler.
+type fakeControl struct {
+	t *testing.T
+
+	// Arguments used to start the client.
+	Args []string
+
+	// Commands received, in order.
+	Commands []string
+}
+
+type fakeControlReply struct {
+	Output []string
+	Failed bool
+}
+
+// newFakeControlDriver builds a ControlDriver connected to a fake control
+// mode client that replies to commands with handle.
+func newFakeControlDriver(t *testing.T, handle func(cmd string) fakeControlReply) (*ControlDriver, *fakeControl) {
+	fake := &fakeControl{t: t}
+	driver := &ControlDriver{
+		log: logtest.NewLogger(t),
+		dial: func(path string, args ...string) (*controlConn, error) {
+			fake.Args = append([]string{path}, args...)
+
+			stdinR, stdinW := io.Pipe()
+			stdoutR, stdoutW := io.Pipe()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				defer func() { _ = stdoutW.Close() }()
+				fake.serve(stdinR, stdoutW, handle)
+			}()
+
+			return newControlConn(stdinW, stdoutR, func() error {
+				<-done
+				return nil
+			}), nil
+		},
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, driver.Close())
+	})
+	return driver, fake
+}
+
+func (f *fakeControl) serve(r io.Reader, w io.Writer, handle func(string) fakeControlReply) {
+	// Reply to the attach-session first, along with some noise.
+	_, _ = io.WriteString(w, "%begin 1 100 0"+"\n")
+	_, _ = io.WriteString(w, "%end 1 100 0"+"\n")
+	_, _ = io.WriteString(w, "%session-changed $0 main"+"\n")
+
+	scan := bufio.NewScanner(r)
+	for n := 101; scan.Scan(); n++ {
+		cmd := scan.Text()
+		f.Commands = append(f.Commands, cmd)
+		reply := handle(cmd)
+
+		guard := fmt.Sprintf("1 %d 1", n)
+		_, _ = io.WriteString(w, "%output %1 hello"+"\n")
+		_, _ = io.WriteString(w, "%begin "+guard+"\n")
+		for _, line := range reply.Output {
+			_, _ = io.WriteString(w, line+"\n")
+		}
+		if reply.Failed {
+			_, _ = io.WriteString(w, "%error "+guard+"\n")
+		} else {
+			_, _ = io.WriteString(w, "%end "+guard+"\n")
+		}
+	}
+}
+
+func TestControlDriver(t *testing.T) {
+	t.Parallel()
+
+	driver, fake := newFakeControlDriver(t, func(cmd string) fakeControlReply {
+		switch {
+		case strings.HasPrefix(cmd, "'capture-pane'"):
+			return fakeControlReply{Output: []string{
+				"foo",
+				// Looks like the end of a reply, but isn't.
+				"%end 1 2 1",
+				"bar",
+			}}
+		case strings.HasPrefix(cmd, "'display-message'"):
+			return fakeControlReply{Output: []string{
+				"%42" + _formatTab + "80",
+			}}
+		case strings.HasPrefix(cmd, "'swap-pane'"):
+			return fakeControlReply{
+				Output: []string{"can't find pane: %99"},
+				Failed: true,
+			}
+		default:
+			return fakeControlReply{}
+		}
+	})
+	driver.Session = "%1"
+	driver.SocketName = "pairing"
+
+	out, err := driver.CapturePane(CapturePaneRequest{Pane: "%42"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo\n%end 1 2 1\nbar\n", string(out))
+
+	out, err = driver.DisplayMessage(DisplayMessageRequest{
+		Pane:    "%42",
+		Message: "#{pane_id}\t#{pane_width}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "%42\t80\n", string(out))
+
+	err = driver.SwapPane(SwapPaneRequest{Source: "%99", Destination: "%42"})
+	assert.ErrorContains(t, err, "swap-pane: can't find pane: %99")
+
+	require.NoError(t, driver.SetOption(SetOptionRequest{
+		Global: true,
+		Name:   "@foo",
+		Value:  "it's",
+	}))
+
+	assert.Equal(t, []string{"tmux", "-L", "pairing", "-C", "attach-session", "-t", "%1"}, fake.Args,
+		"must connect only once")
+	assert.Equal(t, []string{
+		`'capture-pane' '-p' '-t' '%42'`,
+		`'display-message' '-p' '-t' '%42' '#{pane_id}` + _formatTab + `#{pane_width}'`,
+		`'swap-pane' '-t' '%42' '-s' '%99'`,
+		`'set-option' '-g' '@foo' 'it'\''s'`,
+	}, fake.Commands)
+}
+
+func TestControlDriver_newlines(t *testing.T) {
+	t.Parallel()
+
+	driver, fake := newFakeControlDriver(t, func(string) fakeControlReply {
+		return fakeControlReply{}
+	})
+
+	err := driver.SetOption(SetOptionRequest{Name: "@foo", Value: "a\nb"})
+	assert.ErrorContains(t, err, "arguments cannot contain newlines")
+	assert.Empty(t, fake.Commands, "nothing must be sent")
+}
+
+func TestControlDriver_closed(t *testing.T) {
+	t.Parallel()
+
+	driver, _ := newFakeControlDriver(t, func(string) fakeControlReply {
+		return fakeControlReply{}
+	})
+
+	require.NoError(t, driver.SetOption(SetOptionRequest{Name: "@foo", Value: "bar"}))
+	require.NoError(t, driver.Close())
+
+	err := driver.SetOption(SetOptionRequest{Name: "@foo", Value: "bar"})
+	assert.ErrorContains(t, err, "connection is closed")
+}
+
+func TestControlDriver_exited(t *testing.T) {
+	t.Parallel()
+
+	// The client exits right away, e.g. if the session doesn't exist.
+	driver := &ControlDriver{
+		log: logtest.NewLogger(t),
+		dial: func(string, ...string) (*controlConn, error) {
+			stdout := strings.NewReader(joinLines(
+				"%begin 1 100 0",
+				"can't find session: nope",
+				"%error 1 100 0",
+				"%exit",
+			))
+			return newControlConn(nopWriteCloser{io.Discard}, stdout, nil), nil
+		},
+	}
+
+	_, err := driver.ShowOptions(ShowOptionsRequest{Global: true})
+	assert.ErrorContains(t, err, "show-options: tmux control mode connection closed")
+}
+
+// TestControlDriver_tmux runs the ControlDriver against a real tmux server.
+func TestControlDriver_tmux(t *testing.T) {
+	tmux, err := exec.LookPath("tmux")
+	if err != nil {
+		t.Skipf("tmux not found: %v", err)
+	}
+
+	t.Parallel()
+
+	// Isolate the tmux server from the user's.
+	socket := filepath.Join(t.TempDir(), "tmux.sock")
+	start := exec.Command(tmux, "-S", socket,
+		"new-session", "-d", "-s", "fastcopy-test", "-x", "80", "-y", "24")
+	require.NoError(t, start.Run(), "start tmux server")
+	t.Cleanup(func() {
+		_ = exec.Command(tmux, "-S", socket, "kill-server").Run()
+	})
+
+	driver := &ControlDriver{
+		Path:       tmux,
+		SocketPath: socket,
+		Session:    "fastcopy-test",
+		log:        logtest.NewLogger(t),
+	}
+	defer func() {
+		assert.NoError(t, driver.Close())
+	}()
+
+	info, err := InspectPane(driver, "")
+	require.NoError(t, err)
+	assert.Equal(t, 80, info.Width)
+	assert.Equal(t, 24, info.Height)
+
+	// The control client is the only one attached, and it has no
+	// terminal.
+	clients, err := InspectClients(driver, info.ID)
+	require.NoError(t, err)
+	if assert.Len(t, clients, 1) {
+		assert.True(t, clients[0].ControlMode)
+		assert.Empty(t, clients[0].TTY)
+	}
+
+	require.NoError(t, driver.SetOption(SetOptionRequest{
+		Global: true,
+		Name:   "@fastcopy-test",
+		Value:  "hello # 'world'",
+	}))
+	out, err := driver.ShowOptions(ShowOptionsRequest{Global: true})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `@fastcopy-test "hello # 'world'"`)
+
+	require.NoError(t, exec.Command(tmux, "-S", socket,
+		"set-buffer", "-b", "fastcopy-test", "foo\nbar").Run())
+	buffers, err := InspectBuffers(driver)
+	require.NoError(t, err)
+	assert.Equal(t, []*BufferInfo{{Name: "fastcopy-test", Size: 7}}, buffers)
+	out, err = driver.ShowBuffer(ShowBufferRequest{Name: "fastcopy-test"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(out))
+
+	// Buffers with newlines are set with a separate tmux process.
+	for _, data := range []string{"it's #{a}", "ls -l;", "foo\nbar;"} {
+		require.NoError(t, driver.SetBuffer(SetBufferRequest{
+			Name: "fastcopy-test",
+			Data: data,
+		}))
+		out, err = driver.ShowBuffer(ShowBufferRequest{Name: "fastcopy-test"})
+		require.NoError(t, err)
+		assert.Equal(t, data+"\n", string(out))
+	}
+	require.NoError(t, driver.SetBuffer(SetBufferRequest{
+		Name:   "fastcopy-test",
+		Data:   " baz",
+		Append: true,
+	}))
+	out, err = driver.ShowBuffer(ShowBufferRequest{Name: "fastcopy-test"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar; baz\n", string(out))
+
+	// Signals sent before waiting are remembered.
+	require.NoError(t, driver.SendSignal("fastcopy-test"))
+	require.NoError(t, driver.WaitForSignal("fastcopy-test"))
+
+	// Waiting blocks until the signal is sent.
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- driver.WaitForSignal("fastcopy-test")
+	}()
+	select {
+	case err := <-waitErr:
+		t.Fatalf("wait-for returned before the signal was sent: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	require.NoError(t, driver.SendSignal("fastcopy-test"))
+	select {
+	case err := <-waitErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait-for did not return after the signal was sent")
+	}
+
+	err = driver.SwapPane(SwapPaneRequest{Source: "%99", Destination: info.ID})
+	assert.ErrorContains(t, err, "swap-pane:")
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+	_, err = driver.NewSession(NewSessionRequest{
+		Detached: true,
+		Env:      []string{"FASTCOPY_TEST=hi there"},
+		Command:  []string{"sh", "-c", `echo "$FASTCOPY_TEST" > "$1"`, "sh", path},
+	})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		bs, err := os.ReadFile(path)
+		return err == nil && string(bs) == "hi there\n"
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func joinLines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -1,5 +1,7 @@
 // Package tmux provides APIs to interact with the tmux(1) terminal multiplexer.
 //
-// It provides a [Driver] interface and a [ShellDriver] implementation.
-// These provides direct, low-level interaction with tmux operations.
+// It provides a [Driver] interface with two implementations:
+// [ShellDriver] runs tmux for each command, and [ControlDriver] runs
+// commands over a single tmux control mode connection.
+// These provide direct, low-level interaction with tmux operations.
 package tmux
@@ -16,6 +16,27 @@ type Driver interface {
 	// output.
 	CapturePane(CapturePaneRequest) ([]byte, error)
 
+	// ListPanes runs the tmux list-panes command and returns its output.
+	ListPanes(ListPanesRequest) ([]byte, error)
+
+	// ListBuffers runs the tmux list-buffers command and returns its
+	// output.
+	ListBuffers(ListBuffersRequest) ([]byte, error)
+
+	// ListClients runs the tmux list-clients command and returns its
+	// output.
+	ListClients(ListClientsRequest) ([]byte, error)
+
+	// ShowBuffer runs the tmux show-buffer command and returns the
+	// contents of the buffer.
+	ShowBuffer(ShowBufferRequest) ([]byte, error)
+
+	// SetBuffer runs the tmux set-buffer command.
+	SetBuffer(SetBufferRequest) error
+
+	// SendKeys runs the tmux send-keys command.
+	SendKeys(SendKeysRequest) error
+
 	// SwapPane runs the tmux swap-pane command.
 	SwapPane(SwapPaneRequest) error
 
@@ -39,6 +60,12 @@ type Driver interface {
 
 	// SetOption runs the tmux set-option command.
 	SetOption(SetOptionRequest) error
+
+	// DisplayPopup runs the tmux display-popup command.
+	DisplayPopup(DisplayPopupRequest) error
+
+	// Version reports the version of tmux.
+	Version() (Version, error)
 }
 
 // SetOptionRequest specifies the parameters for the set-option command.
@@ -96,6 +123,14 @@ type CapturePaneRequest struct {
 	// Start and end positions of the captured text. Negative lines are
 	// positions in history.
 	StartLine, EndLine int
+
+	// Whether lines that were wrapped at the edge of the pane should be
+	// joined back together. Trailing spaces are kept on all lines.
+	JoinLines bool
+
+	// Whether trailing positions that don't contain a character should be
+	// dropped. Requires tmux 3.4 or newer.
+	TrimTrailing bool
 }
 
 func (r CapturePaneRequest) String() string {
@@ -103,6 +138,8 @@ func (r CapturePaneRequest) String() string {
 	b.Put("pane", r.Pane)
 	b.Put("startLine", r.StartLine)
 	b.Put("endLine", r.EndLine)
+	b.Put("joinLines", r.JoinLines)
+	b.Put("trimTrailing", r.TrimTrailing)
 	return b.String()
 }
 
@@ -114,12 +151,131 @@ type DisplayMessageRequest struct {
 
 	// Message to display.
 	Message string
+
+	// Show the message on the status line of the client instead of
+	// returning it.
+	StatusLine bool
 }
 
 func (r DisplayMessageRequest) String() string {
 	var b stringobj.Builder
 	b.Put("pane", r.Pane)
 	b.Put("message", r.Message)
+	b.Put("statusLine", r.StatusLine)
+	return b.String()
+}
+
+// ListPanesRequest specifies the parameters for a list-panes command.
+type ListPanesRequest struct {
+	// Window whose panes to list. Defaults to current.
+	//
+	// If Session is set, this identifies the session instead.
+	Window string
+
+	// Whether to list the panes of all windows in the session.
+	Session bool
+
+	// Whether to list all panes on the server. Window and Session are
+	// ignored if this is set.
+	All bool
+
+	// Format to print for each pane, one per line.
+	Format string
+}
+
+func (r ListPanesRequest) String() string {
+	var b stringobj.Builder
+	b.Put("window", r.Window)
+	b.Put("session", r.Session)
+	b.Put("all", r.All)
+	b.Put("format", r.Format)
+	return b.String()
+}
+
+// ListBuffersRequest specifies the parameters for a list-buffers command.
+type ListBuffersRequest struct {
+	// Format to print for each buffer, one per line.
+	Format string
+}
+
+func (r ListBuffersRequest) String() string {
+	var b stringobj.Builder
+	b.Put("format", r.Format)
+	return b.String()
+}
+
+// ListClientsRequest specifies the parameters for a list-clients command.
+type ListClientsRequest struct {
+	// Session whose clients to list. This may also be a pane or window
+	// inside the session. Defaults to all clients on the server.
+	Session string
+
+	// Format to print for each client, one per line.
+	Format string
+}
+
+func (r ListClientsRequest) String() string {
+	var b stringobj.Builder
+	b.Put("session", r.Session)
+	b.Put("format", r.Format)
+	return b.String()
+}
+
+// ShowBufferRequest specifies the parameters for a show-buffer command.
+type ShowBufferRequest struct {
+	// Name of the buffer to show. Defaults to the most recent buffer.
+	Name string
+}
+
+func (r ShowBufferRequest) String() string {
+	var b stringobj.Builder
+	b.Put("name", r.Name)
+	return b.String()
+}
+
+// SetBufferRequest specifies the parameters for a set-buffer command.
+type SetBufferRequest struct {
+	// Name of the buffer to set. If empty, a new buffer is added.
+	Name string
+
+	// Data is the new contents of the buffer.
+	Data string
+
+	// Append adds Data to the end of the buffer instead of replacing it.
+	Append bool
+
+	// Clipboard also sends the buffer to the system clipboard if tmux is
+	// set up for it. This requires tmux 3.2 or newer.
+	Clipboard bool
+}
+
+func (r SetBufferRequest) String() string {
+	var b stringobj.Builder
+	b.Put("name", r.Name)
+	b.Put("data", r.Data)
+	b.Put("append", r.Append)
+	b.Put("clipboard", r.Clipboard)
+	return b.String()
+}
+
+// SendKeysRequest specifies the parameters for a send-keys command.
+type SendKeysRequest struct {
+	// Pane to send the keys to. Defaults to current.
+	Pane string
+
+	// Keys to send. With Literal, these are sent as-is instead of being
+	// looked up as key names like "Enter".
+	Keys []string
+
+	// Literal sends the keys as literal UTF-8 characters.
+	Literal bool
+}
+
+func (r SendKeysRequest) String() string {
+	var b stringobj.Builder
+	b.Put("pane", r.Pane)
+	b.Put("keys", r.Keys)
+	b.Put("literal", r.Literal)
 	return b.String()
 }
 
@@ -130,12 +286,57 @@ type SwapPaneRequest struct {
 
 	// Destination pane to swap the source with.
 	Destination string
+
+	// Whether a zoomed window should stay zoomed after the swap.
+	// Requires tmux 3.1 or newer.
+	KeepZoom bool
 }
 
 func (r SwapPaneRequest) String() string {
 	var b stringobj.Builder
 	b.Put("source", r.Source)
 	b.Put("destination", r.Destination)
+	b.Put("keepZoom", r.KeepZoom)
+	return b.String()
+}
+
+// DisplayPopupRequest specifies the parameters for a display-popup command.
+type DisplayPopupRequest struct {
+	// Pane relative to which the popup is positioned. Defaults to current.
+	Pane string
+
+	// Position of the popup. These may use formats like
+	// #{popup_pane_left} to position the popup relative to the pane.
+	// Defaults to the center.
+	X, Y string
+
+	// Size of the popup.
+	Width, Height int
+
+	// Whether the popup should be drawn without a border.
+	NoBorder bool
+
+	// Whether the popup should be closed when the command exits.
+	CloseOnExit bool
+
+	// Additional environment variables to pass to the command.
+	Env []string
+
+	// Command to run inside the popup. Must have at least one element.
+	Command []string
+}
+
+func (r DisplayPopupRequest) String() string {
+	var b stringobj.Builder
+	b.Put("pane", r.Pane)
+	b.Put("x", r.X)
+	b.Put("y", r.Y)
+	b.Put("width", r.Width)
+	b.Put("height", r.Height)
+	b.Put("noBorder", r.NoBorder)
+	b.Put("closeOnExit", r.CloseOnExit)
+	b.Put("env", r.Env)
+	b.Put("command", r.Command)
 	return b.String()
 }
 
@@ -1,6 +1,10 @@
 package tmux
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/abhinav/tmux-fastcopy/internal/stringobj"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxfmt"
 )
@@ -29,6 +33,19 @@ type PaneInfo struct {
 
 	// Current path of the pane, if available.
 	CurrentPath string
+
+	// Position of the top-left corner of the pane inside its window.
+	Left, Top int
+
+	// Name of the session, and indexes of the window and the pane.
+	SessionName            string
+	WindowIndex, PaneIndex int
+}
+
+// Location reports where the pane is in the form session:window.pane,
+// the same form that tmux uses in its choose-tree.
+func (i *PaneInfo) Location() string {
+	return fmt.Sprintf("%v:%d.%d", i.SessionName, i.WindowIndex, i.PaneIndex)
 }
 
 func (i *PaneInfo) String() string {
@@ -40,6 +57,11 @@ func (i *PaneInfo) String() string {
 	b.Put("mode", i.Mode)
 	b.Put("scrollPosition", i.ScrollPosition)
 	b.Put("currentPath", i.CurrentPath)
+	b.Put("left", i.Left)
+	b.Put("top", i.Top)
+	b.Put("sessionName", i.SessionName)
+	b.Put("windowIndex", i.WindowIndex)
+	b.Put("paneIndex", i.PaneIndex)
 	return b.String()
 }
 
@@ -63,18 +85,192 @@ var (
 		Then: tmuxfmt.Var("scroll_position"),
 		Else: tmuxfmt.Int(0),
 	}
+	_paneLeft     = tmuxfmt.Var("pane_left")
+	_paneTop      = tmuxfmt.Var("pane_top")
+	_paneIndex    = tmuxfmt.Var("pane_index")
+	_sessionName  = tmuxfmt.Var("session_name")
+	_windowIndex  = tmuxfmt.Var("window_index")
 	_windowID     = tmuxfmt.Var("window_id")
 	_windowZoomed = tmuxfmt.Var("window_zoomed_flag")
+
+	_bufferName = tmuxfmt.Var("buffer_name")
+	_bufferSize = tmuxfmt.Var("buffer_size")
+
+	_clientTTY         = tmuxfmt.Var("client_tty")
+	_clientControlMode = tmuxfmt.Var("client_control_mode")
+	_clientActivity    = tmuxfmt.Var("client_activity")
 )
 
 // InspectPane inspects a tmux pane and reports information about it. The
 // argument identifies the pane we want to inspect, defaulting to the current
 // pane if none is specified.
 func InspectPane(driver Driver, identifier string) (*PaneInfo, error) {
+	var info PaneInfo
+	msg, parse := paneInfoCapturer(&info).Prepare()
+	out, err := driver.DisplayMessage(DisplayMessageRequest{
+		Pane:    identifier,
+		Message: msg,
+	})
+	if err == nil {
+		err = parse(out)
+	}
+	return &info, err
+}
+
+// InspectWindow inspects all panes of a tmux window and reports information
+// about them. The argument identifies the window, defaulting to the current
+// window if none is specified.
+func InspectWindow(driver Driver, window string) ([]*PaneInfo, error) {
+	return inspectPanes(driver, ListPanesRequest{Window: window})
+}
+
+// InspectSession inspects all panes of all windows in a tmux session and
+// reports information about them. The argument identifies the session, or a
+// pane or window inside it, defaulting to the current session if none is
+// specified.
+func InspectSession(driver Driver, session string) ([]*PaneInfo, error) {
+	return inspectPanes(driver, ListPanesRequest{
+		Window:  session,
+		Session: true,
+	})
+}
+
+// InspectServer inspects all panes on the tmux server and reports information
+// about them.
+func InspectServer(driver Driver) ([]*PaneInfo, error) {
+	return inspectPanes(driver, ListPanesRequest{All: true})
+}
+
+// inspectPanes inspects the panes listed by the given list-panes request.
+// The request's Format is filled in.
+func inspectPanes(driver Driver, req ListPanesRequest) ([]*PaneInfo, error) {
+	var info PaneInfo
+	var parse func([]byte) error
+	req.Format, parse = paneInfoCapturer(&info).Prepare()
+	out, err := driver.ListPanes(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var panes []*PaneInfo
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		info = PaneInfo{}
+		if err := parse([]byte(line)); err != nil {
+			return nil, err
+		}
+		pane := info
+		panes = append(panes, &pane)
+	}
+	return panes, nil
+}
+
+// BufferInfo reports information about a tmux paste buffer.
+type BufferInfo struct {
+	Name string
+	Size int // in bytes
+}
+
+func (i *BufferInfo) String() string {
+	var b stringobj.Builder
+	b.Put("name", i.Name)
+	b.Put("size", i.Size)
+	return b.String()
+}
+
+// InspectBuffers reports information about the paste buffers on the tmux
+// server, most recent first. This is empty if there are no buffers.
+func InspectBuffers(driver Driver) ([]*BufferInfo, error) {
 	var (
-		info PaneInfo
+		info BufferInfo
 		fc   tmuxfmt.Capturer
 	)
+	fc.StringVar(&info.Name, _bufferName)
+	fc.IntVar(&info.Size, _bufferSize)
+	format, parse := fc.Prepare()
+
+	out, err := driver.ListBuffers(ListBuffersRequest{Format: format})
+	if err != nil {
+		return nil, err
+	}
+
+	var buffers []*BufferInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		info = BufferInfo{}
+		if err := parse([]byte(line)); err != nil {
+			return nil, err
+		}
+		buf := info
+		buffers = append(buffers, &buf)
+	}
+	return buffers, nil
+}
+
+// ClientInfo reports information about a client attached to tmux.
+type ClientInfo struct {
+	// Terminal of the client. This is empty for control mode clients.
+	TTY string
+
+	// Whether this is a control mode client.
+	ControlMode bool
+
+	// Time of the client's last activity, in seconds since the Unix epoch.
+	Activity int
+}
+
+func (i *ClientInfo) String() string {
+	var b stringobj.Builder
+	b.Put("tty", i.TTY)
+	b.Put("controlMode", i.ControlMode)
+	b.Put("activity", i.Activity)
+	return b.String()
+}
+
+// InspectClients reports information about the clients attached to a tmux
+// session, most recently active first. The argument identifies the session,
+// or a pane or window inside it, defaulting to all clients on the server if
+// none is specified.
+func InspectClients(driver Driver, session string) ([]*ClientInfo, error) {
+	var (
+		info ClientInfo
+		fc   tmuxfmt.Capturer
+	)
+	fc.StringVar(&info.TTY, _clientTTY)
+	fc.BoolVar(&info.ControlMode, _clientControlMode)
+	fc.IntVar(&info.Activity, _clientActivity)
+	format, parse := fc.Prepare()
+
+	out, err := driver.ListClients(ListClientsRequest{
+		Session: session,
+		Format:  format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []*ClientInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		info = ClientInfo{}
+		if err := parse([]byte(line)); err != nil {
+			return nil, err
+		}
+		client := info
+		clients = append(clients, &client)
+	}
+	sort.SliceStable(clients, func(i, j int) bool {
+		return clients[i].Activity > clients[j].Activity
+	})
+	return clients, nil
+}
+
+// paneInfoCapturer builds a Capturer that fills the given PaneInfo.
+func paneInfoCapturer(info *PaneInfo) *tmuxfmt.Capturer {
+	var fc tmuxfmt.Capturer
 	fc.StringVar(&info.ID, _paneID)
 	fc.StringVar(&info.WindowID, _windowID)
 	fc.IntVar(&info.Width, _paneWidth)
@@ -83,14 +279,10 @@ func InspectPane(driver Driver, identifier string) (*PaneInfo, error) {
 	fc.IntVar(&info.ScrollPosition, _paneScrollPosition)
 	fc.BoolVar(&info.WindowZoomed, _windowZoomed)
 	fc.StringVar(&info.CurrentPath, _paneCurrentPath)
-
-	msg, parse := fc.Prepare()
-	out, err := driver.DisplayMessage(DisplayMessageRequest{
-		Pane:    identifier,
-		Message: msg,
-	})
-	if err == nil {
-		err = parse(out)
-	}
-	return &info, err
+	fc.IntVar(&info.Left, _paneLeft)
+	fc.IntVar(&info.Top, _paneTop)
+	fc.StringVar(&info.SessionName, _sessionName)
+	fc.IntVar(&info.WindowIndex, _windowIndex)
+	fc.IntVar(&info.PaneIndex, _paneIndex)
+	return &fc
 }
@@ -13,7 +13,7 @@ import (
 func TestInspectPane(t *testing.T) {
 	t.Parallel()
 
-	message := []byte("%42\t@123\t80\t40\tcopy-mode\t40\t0\t/home/user/dir")
+	message := []byte("%42\t@123\t80\t40\tcopy-mode\t40\t0\t/home/user/dir\t81\t0\tmain\t2\t1")
 
 	ctrl := gomock.NewController(t)
 	mockTmux := tmuxtest.NewMockDriver(ctrl)
@@ -32,7 +32,12 @@ func TestInspectPane(t *testing.T) {
 		Mode:           tmux.CopyMode,
 		ScrollPosition: 40,
 		CurrentPath:    "/home/user/dir",
+		Left:           81,
+		SessionName:    "main",
+		WindowIndex:    2,
+		PaneIndex:      1,
 	}, got)
+	assert.Equal(t, "main:2.1", got.Location())
 
 	t.Run("String", func(t *testing.T) {
 		t.Parallel()
@@ -45,5 +50,157 @@ func TestInspectPane(t *testing.T) {
 		assert.Contains(t, s, "mode: copy-mode")
 		assert.Contains(t, s, "scrollPosition: 40")
 		assert.Contains(t, s, "currentPath: /home/user/dir")
+		assert.Contains(t, s, "left: 81")
+		assert.Contains(t, s, "sessionName: main")
+		assert.Contains(t, s, "windowIndex: 2")
+		assert.Contains(t, s, "paneIndex: 1")
 	})
 }
+
+func TestInspectWindow(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListPanes(gomock.Any()).
+		DoAndReturn(func(req tmux.ListPanesRequest) ([]byte, error) {
+			assert.Equal(t, "@1", req.Window)
+			return []byte("%1\t@1\t40\t24\tnormal-mode\t0\t0\t/home\t0\t0\n" +
+				"%2\t@1\t39\t24\tcopy-mode\t10\t0\t/tmp\t41\t0\n"), nil
+		})
+
+	got, err := tmux.InspectWindow(mockTmux, "@1")
+	require.NoError(t, err)
+	assert.Equal(t, []*tmux.PaneInfo{
+		{
+			ID:          "%1",
+			WindowID:    "@1",
+			Width:       40,
+			Height:      24,
+			Mode:        tmux.NormalMode,
+			CurrentPath: "/home",
+		},
+		{
+			ID:             "%2",
+			WindowID:       "@1",
+			Width:          39,
+			Height:         24,
+			Mode:           tmux.CopyMode,
+			ScrollPosition: 10,
+			CurrentPath:    "/tmp",
+			Left:           41,
+		},
+	}, got)
+}
+
+func TestInspectWindow_error(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListPanes(gomock.Any()).
+		Return([]byte("%1\t@1\tabc\n"), nil)
+
+	_, err := tmux.InspectWindow(mockTmux, "@1")
+	assert.Error(t, err)
+}
+
+func TestInspectSession(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListPanes(gomock.Any()).
+		DoAndReturn(func(req tmux.ListPanesRequest) ([]byte, error) {
+			assert.Equal(t, "%1", req.Window)
+			assert.True(t, req.Session)
+			assert.False(t, req.All)
+			return []byte("%1\t@1\t80\t24\tnormal-mode\t0\t0\t/home\t0\t0\tmain\t0\t0\n" +
+				"%3\t@2\t80\t24\tnormal-mode\t0\t0\t/tmp\t0\t0\tmain\t1\t0\n"), nil
+		})
+
+	got, err := tmux.InspectSession(mockTmux, "%1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "main:0.0", got[0].Location())
+	assert.Equal(t, "main:1.0", got[1].Location())
+}
+
+func TestInspectServer(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListPanes(gomock.Any()).
+		DoAndReturn(func(req tmux.ListPanesRequest) ([]byte, error) {
+			assert.True(t, req.All)
+			return []byte("%1\t@1\t80\t24\tnormal-mode\t0\t0\t/home\t0\t0\tmain\t0\t0\n" +
+				"%2\t@3\t80\t24\tnormal-mode\t0\t0\t/tmp\t0\t0\tother\t0\t0\n"), nil
+		})
+
+	got, err := tmux.InspectServer(mockTmux)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "main:0.0", got[0].Location())
+	assert.Equal(t, "other:0.0", got[1].Location())
+}
+
+func TestInspectBuffers(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListBuffers(gomock.Any()).
+		Return([]byte("buffer2\t5\nbuffer1\t1234\n"), nil)
+
+	got, err := tmux.InspectBuffers(mockTmux)
+	require.NoError(t, err)
+	assert.Equal(t, []*tmux.BufferInfo{
+		{Name: "buffer2", Size: 5},
+		{Name: "buffer1", Size: 1234},
+	}, got)
+}
+
+func TestInspectBuffers_empty(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListBuffers(gomock.Any()).
+		Return(nil, nil)
+
+	got, err := tmux.InspectBuffers(mockTmux)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestInspectClients(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	mockTmux.EXPECT().
+		ListClients(gomock.Any()).
+		Return([]byte("/dev/pts/1\t0\t100\n\t1\t300\n/dev/pts/2\t0\t200\n"), nil)
+
+	got, err := tmux.InspectClients(mockTmux, "%42")
+	require.NoError(t, err)
+	assert.Equal(t, []*tmux.ClientInfo{
+		{ControlMode: true, Activity: 300},
+		{TTY: "/dev/pts/2", Activity: 200},
+		{TTY: "/dev/pts/1", Activity: 100},
+	}, got)
+}
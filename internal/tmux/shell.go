@@ -1,10 +1,9 @@
 package tmux
 
 import (
-	"errors"
 	"io"
 	"os/exec"
-	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/abhinav/tmux-fastcopy/internal/log"
@@ -36,9 +35,18 @@ type ShellDriver struct {
 	// Path to the env command. Defaults to /usr/bin/env.
 	Env string
 
+	// Name or path of the socket of the tmux server to talk to, if not
+	// the default one. These are the -L and -S flags of tmux.
+	SocketName string
+	SocketPath string
+
 	log  *log.Logger
 	run  *runner
 	once sync.Once
+
+	versionOnce sync.Once
+	version     Version
+	versionErr  error
 }
 
 var _ Driver = (*ShellDriver)(nil)
@@ -70,10 +78,26 @@ func (s *ShellDriver) SetLogger(log *log.Logger) {
 }
 
 func (s *ShellDriver) cmd(args ...string) *exec.Cmd {
-	cmd := exec.Command(s.Path, args...)
+	cmdArgs := socketArgs(s.SocketName, s.SocketPath)
+	for _, arg := range args {
+		cmdArgs = append(cmdArgs, escapeSeparator(arg))
+	}
+	cmd := exec.Command(s.Path, cmdArgs...)
 	return cmd
 }
 
+// escapeSeparator escapes a trailing ";" in an argument.
+//
+// tmux treats arguments that end with ";" as the end of a command and drops
+// the ";", unless it's escaped as "\;". This matters for arbitrary text like
+// the contents of a buffer.
+func escapeSeparator(arg string) string {
+	if s, ok := strings.CutSuffix(arg, ";"); ok {
+		return s + `\;`
+	}
+	return arg
+}
+
 // errorWriter sets the provided io.Writers to the same log.Writer and returns
 // a function to close them.
 //
@@ -91,38 +115,10 @@ func (s *ShellDriver) errorWriter(ws ...*io.Writer) (cleanup func()) {
 func (s *ShellDriver) NewSession(req NewSessionRequest) ([]byte, error) {
 	s.init()
 
-	args := []string{"new-session"}
-	if n := req.Name; len(n) > 0 {
-		args = append(args, "-s", n)
-	}
-	if fmt := req.Format; len(fmt) > 0 {
-		args = append(args, "-P", "-F", fmt)
-	}
-	if w := req.Width; w > 0 {
-		args = append(args, "-x", strconv.Itoa(w))
-	}
-	if h := req.Height; h > 0 {
-		args = append(args, "-y", strconv.Itoa(h))
-	}
-	if req.Detached {
-		args = append(args, "-d")
-	}
-
-	// We could use the -e flg to set the environment variables, but that
-	// was added in tmux 3.2. Instead, use,
-	//
-	//   /usr/bin/env K1=V1 K2=V2 cmd "$1" "$2" ...
-	if len(req.Env) > 0 {
-		if len(req.Command) == 0 {
-			return nil, errors.New("env can be set only if command is set")
-		}
-		setenv := make([]string, len(req.Env)+1)
-		setenv[0] = s.Env
-		copy(setenv[1:], req.Env)
-		args = append(args, setenv...)
+	args, err := newSessionArgs(s.Env, req)
+	if err != nil {
+		return nil, err
 	}
-
-	args = append(args, req.Command...)
 	cmd := s.cmd(args...)
 	defer s.errorWriter(&cmd.Stderr)()
 
@@ -134,17 +130,7 @@ func (s *ShellDriver) NewSession(req NewSessionRequest) ([]byte, error) {
 func (s *ShellDriver) CapturePane(req CapturePaneRequest) ([]byte, error) {
 	s.init()
 
-	args := []string{"capture-pane", "-p", "-J"}
-	if len(req.Pane) > 0 {
-		args = append(args, "-t", req.Pane)
-	}
-	if s := req.StartLine; s != 0 {
-		args = append(args, "-S", strconv.Itoa(s))
-	}
-	if e := req.EndLine; e != 0 {
-		args = append(args, "-E", strconv.Itoa(e))
-	}
-	cmd := s.cmd(args...)
+	cmd := s.cmd(capturePaneArgs(req)...)
 	defer s.errorWriter(&cmd.Stderr)()
 
 	s.log.Debugf("capture pane: %v", req)
@@ -155,13 +141,7 @@ func (s *ShellDriver) CapturePane(req CapturePaneRequest) ([]byte, error) {
 func (s *ShellDriver) SetOption(req SetOptionRequest) error {
 	s.init()
 
-	args := []string{"set-option"}
-	if req.Global {
-		args = append(args, "-g")
-	}
-	args = append(args, req.Name, req.Value)
-
-	cmd := s.cmd(args...)
+	cmd := s.cmd(setOptionArgs(req)...)
 	defer s.errorWriter(&cmd.Stderr)()
 
 	s.log.Debugf("set-option: %v", req)
@@ -172,32 +152,107 @@ func (s *ShellDriver) SetOption(req SetOptionRequest) error {
 func (s *ShellDriver) DisplayMessage(req DisplayMessageRequest) ([]byte, error) {
 	s.init()
 
-	args := []string{"display-message", "-p"}
-	if len(req.Pane) > 0 {
-		args = append(args, "-t", req.Pane)
-	}
-	args = append(args, req.Message)
-
-	cmd := s.cmd(args...)
+	cmd := s.cmd(displayMessageArgs(req)...)
 	defer s.errorWriter(&cmd.Stderr)()
 
 	s.log.Debugf("display message: %v", req)
+	out, err := s.run.Output(cmd)
+	return formatOutput(out), err
+}
+
+// ListPanes runs the list-panes command and returns its output.
+func (s *ShellDriver) ListPanes(req ListPanesRequest) ([]byte, error) {
+	s.init()
+
+	cmd := s.cmd(listPanesArgs(req)...)
+	defer s.errorWriter(&cmd.Stderr)()
+
+	s.log.Debugf("list panes: %v", req)
+	out, err := s.run.Output(cmd)
+	return formatOutput(out), err
+}
+
+// ListBuffers runs the list-buffers command and returns its output.
+func (s *ShellDriver) ListBuffers(req ListBuffersRequest) ([]byte, error) {
+	s.init()
+
+	cmd := s.cmd(listBuffersArgs(req)...)
+	defer s.errorWriter(&cmd.Stderr)()
+
+	s.log.Debugf("list buffers: %v", req)
+	out, err := s.run.Output(cmd)
+	return formatOutput(out), err
+}
+
+// ListClients runs the list-clients command and returns its output.
+func (s *ShellDriver) ListClients(req ListClientsRequest) ([]byte, error) {
+	s.init()
+
+	cmd := s.cmd(listClientsArgs(req)...)
+	defer s.errorWriter(&cmd.Stderr)()
+
+	s.log.Debugf("list clients: %v", req)
+	out, err := s.run.Output(cmd)
+	return formatOutput(out), err
+}
+
+// ShowBuffer runs the show-buffer command and returns the contents of the
+// buffer.
+func (s *ShellDriver) ShowBuffer(req ShowBufferRequest) ([]byte, error) {
+	s.init()
+
+	cmd := s.cmd(showBufferArgs(req)...)
+	defer s.errorWriter(&cmd.Stderr)()
+
+	s.log.Debugf("show buffer: %v", req)
 	return s.run.Output(cmd)
 }
 
+// SetBuffer runs the set-buffer command.
+func (s *ShellDriver) SetBuffer(req SetBufferRequest) error {
+	s.init()
+
+	cmd := s.cmd(setBufferArgs(req)...)
+	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
+
+	s.log.Debugf("set buffer: %v", req)
+	return s.run.Run(cmd)
+}
+
+// SendKeys runs the send-keys command.
+func (s *ShellDriver) SendKeys(req SendKeysRequest) error {
+	s.init()
+
+	cmd := s.cmd(sendKeysArgs(req)...)
+	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
+
+	s.log.Debugf("send keys: %v", req)
+	return s.run.Run(cmd)
+}
+
 // SwapPane runs the swap-pane command.
 func (s *ShellDriver) SwapPane(req SwapPaneRequest) error {
 	s.init()
 
-	args := []string{"swap-pane", "-t", req.Destination}
-	if s := req.Source; len(s) > 0 {
-		args = append(args, "-s", s)
-	}
+	cmd := s.cmd(swapPaneArgs(req)...)
+	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
+
+	s.log.Debugf("swap pane: %v", req)
+	return s.run.Run(cmd)
+}
+
+// DisplayPopup runs the display-popup command.
+func (s *ShellDriver) DisplayPopup(req DisplayPopupRequest) error {
+	s.init()
 
+	args, err := displayPopupArgs(s.Env, req)
+	if err != nil {
+		return err
+	}
 	cmd := s.cmd(args...)
 	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
 
-	s.log.Debugf("swap pane: %v", req)
+	s.log.Debugf("display popup: %v", req)
 	return s.run.Run(cmd)
 }
 
@@ -205,12 +260,7 @@ func (s *ShellDriver) SwapPane(req SwapPaneRequest) error {
 func (s *ShellDriver) ResizePane(req ResizePaneRequest) error {
 	s.init()
 
-	args := []string{"resize-pane", "-t", req.Target}
-	if req.ToggleZoom {
-		args = append(args, "-Z")
-	}
-
-	cmd := s.cmd(args...)
+	cmd := s.cmd(resizePaneArgs(req)...)
 	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
 
 	s.log.Debugf("resize pane: %v", req)
@@ -221,19 +271,7 @@ func (s *ShellDriver) ResizePane(req ResizePaneRequest) error {
 func (s *ShellDriver) ResizeWindow(req ResizeWindowRequest) error {
 	s.init()
 
-	args := []string{"resize-window"}
-	if w := req.Window; len(w) > 0 {
-		args = append(args, "-t", w)
-	}
-
-	if w := req.Width; w > 0 {
-		args = append(args, "-x", strconv.Itoa(w))
-	}
-	if h := req.Height; h > 0 {
-		args = append(args, "-y", strconv.Itoa(h))
-	}
-
-	cmd := s.cmd(args...)
+	cmd := s.cmd(resizeWindowArgs(req)...)
 	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
 
 	s.log.Debugf("resize window: %v", req)
@@ -243,7 +281,7 @@ func (s *ShellDriver) ResizeWindow(req ResizeWindowRequest) error {
 // WaitForSignal runs the wait-for command.
 func (s *ShellDriver) WaitForSignal(sig string) error {
 	s.init()
-	cmd := s.cmd("wait-for", sig)
+	cmd := s.cmd(waitForArgs(sig)...)
 	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
 
 	s.log.Debugf("wait-for: %v", sig)
@@ -253,7 +291,7 @@ func (s *ShellDriver) WaitForSignal(sig string) error {
 // SendSignal runs the wait-for -S command.
 func (s *ShellDriver) SendSignal(sig string) error {
 	s.init()
-	cmd := s.cmd("wait-for", "-S", sig)
+	cmd := s.cmd(sendSignalArgs(sig)...)
 	defer s.errorWriter(&cmd.Stdout, &cmd.Stderr)()
 
 	s.log.Debugf("wait-for -S: %v", sig)
@@ -264,13 +302,37 @@ func (s *ShellDriver) SendSignal(sig string) error {
 func (s *ShellDriver) ShowOptions(req ShowOptionsRequest) ([]byte, error) {
 	s.init()
 
-	args := []string{"show-options"}
-	if req.Global {
-		args = append(args, "-g")
-	}
-	cmd := s.cmd(args...)
+	cmd := s.cmd(showOptionsArgs(req)...)
 	defer s.errorWriter(&cmd.Stderr)()
 
 	s.log.Debugf("show options: %v", req)
 	return s.run.Output(cmd)
 }
+
+// Version runs tmux -V and parses its output. The version is looked up only
+// once.
+func (s *ShellDriver) Version() (Version, error) {
+	s.init()
+
+	s.versionOnce.Do(func() {
+		s.version, s.versionErr = versionOf(s.run, s.cmd("-V"), s.log)
+	})
+	return s.version, s.versionErr
+}
+
+// versionOf runs the given 'tmux -V' command and parses its output.
+func versionOf(run *runner, cmd *exec.Cmd, logger *log.Logger) (Version, error) {
+	writer := &log.Writer{Log: logger, Level: log.Error}
+	defer func() { _ = writer.Close() }()
+	cmd.Stderr = writer
+
+	out, err := run.Output(cmd)
+	if err != nil {
+		return Version{}, err
+	}
+	v, err := ParseVersion(string(out))
+	if err == nil {
+		logger.Debugf("tmux version: %v", v)
+	}
+	return v, err
+}
@@ -93,22 +93,32 @@ func TestCapturePaneArgs(t *testing.T) {
 	}{
 		{
 			desc: "empty",
-			want: []string{"capture-pane", "-p", "-J"},
+			want: []string{"capture-pane", "-p"},
 		},
 		{
 			desc: "pane",
 			give: CapturePaneRequest{Pane: "%42"},
-			want: []string{"capture-pane", "-p", "-J", "-t", "%42"},
+			want: []string{"capture-pane", "-p", "-t", "%42"},
 		},
 		{
 			desc: "start line",
 			give: CapturePaneRequest{StartLine: 42},
-			want: []string{"capture-pane", "-p", "-J", "-S", "42"},
+			want: []string{"capture-pane", "-p", "-S", "42"},
 		},
 		{
 			desc: "end line",
 			give: CapturePaneRequest{EndLine: 42},
-			want: []string{"capture-pane", "-p", "-J", "-E", "42"},
+			want: []string{"capture-pane", "-p", "-E", "42"},
+		},
+		{
+			desc: "join lines",
+			give: CapturePaneRequest{JoinLines: true},
+			want: []string{"capture-pane", "-p", "-J"},
+		},
+		{
+			desc: "trim trailing",
+			give: CapturePaneRequest{TrimTrailing: true},
+			want: []string{"capture-pane", "-p", "-T"},
 		},
 	}
 
@@ -156,6 +166,11 @@ func TestDisplayMessageArgs(t *testing.T) {
 			give: DisplayMessageRequest{Pane: "%42", Message: "#{pane_id}"},
 			want: []string{"display-message", "-p", "-t", "%42", "#{pane_id}"},
 		},
+		{
+			desc: "status line",
+			give: DisplayMessageRequest{Pane: "%42", Message: "hello", StatusLine: true},
+			want: []string{"display-message", "-t", "%42", "hello"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,6 +195,26 @@ func TestDisplayMessageArgs(t *testing.T) {
 	}
 }
 
+func TestDisplayMessageTabs(t *testing.T) {
+	t.Parallel()
+
+	r := newFakeRunner(t)
+	r.ExpectOutput("tmux", "display-message", "-p", "-t", "%42",
+		"#{pane_id}"+_formatTab+"#{pane_width}").
+		Stdout([]byte("%42" + _formatTab + "80\n"))
+
+	driver := ShellDriver{
+		run: r.Runner(),
+		log: logtest.NewLogger(t),
+	}
+	got, err := driver.DisplayMessage(DisplayMessageRequest{
+		Pane:    "%42",
+		Message: "#{pane_id}\t#{pane_width}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "%42\t80\n", string(got))
+}
+
 func TestSetOptionArgs(t *testing.T) {
 	t.Parallel()
 
@@ -218,6 +253,296 @@ func TestSetOptionArgs(t *testing.T) {
 	}
 }
 
+func TestListPanesArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give ListPanesRequest
+		want []string
+	}{
+		{
+			desc: "empty",
+			want: []string{"list-panes"},
+		},
+		{
+			desc: "window",
+			give: ListPanesRequest{Window: "@1"},
+			want: []string{"list-panes", "-t", "@1"},
+		},
+		{
+			desc: "format",
+			give: ListPanesRequest{Window: "@1", Format: "#{pane_id}\t#{pane_left}"},
+			want: []string{"list-panes", "-t", "@1", "-F", "#{pane_id}" + _formatTab + "#{pane_left}"},
+		},
+		{
+			desc: "session",
+			give: ListPanesRequest{Window: "%1", Session: true},
+			want: []string{"list-panes", "-s", "-t", "%1"},
+		},
+		{
+			desc: "all",
+			give: ListPanesRequest{Window: "%1", Session: true, All: true},
+			want: []string{"list-panes", "-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...).
+				Stdout([]byte("%1" + _formatTab + "0\n%2" + _formatTab + "41\n"))
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			got, err := driver.ListPanes(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, "%1\t0\n%2\t41\n", string(got))
+		})
+	}
+}
+
+func TestListBuffersArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give ListBuffersRequest
+		want []string
+	}{
+		{
+			desc: "empty",
+			want: []string{"list-buffers"},
+		},
+		{
+			desc: "format",
+			give: ListBuffersRequest{Format: "#{buffer_name}\t#{buffer_size}"},
+			want: []string{"list-buffers", "-F", "#{buffer_name}" + _formatTab + "#{buffer_size}"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...).
+				Stdout([]byte("buffer1" + _formatTab + "42\n"))
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			got, err := driver.ListBuffers(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, "buffer1\t42\n", string(got))
+		})
+	}
+}
+
+func TestListClientsArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give ListClientsRequest
+		want []string
+	}{
+		{
+			desc: "empty",
+			want: []string{"list-clients"},
+		},
+		{
+			desc: "session",
+			give: ListClientsRequest{Session: "%42"},
+			want: []string{"list-clients", "-t", "%42"},
+		},
+		{
+			desc: "format",
+			give: ListClientsRequest{Format: "#{client_tty}\t#{client_activity}"},
+			want: []string{"list-clients", "-F", "#{client_tty}" + _formatTab + "#{client_activity}"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...).
+				Stdout([]byte("/dev/pts/1" + _formatTab + "42\n"))
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			got, err := driver.ListClients(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, "/dev/pts/1\t42\n", string(got))
+		})
+	}
+}
+
+func TestShowBufferArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give ShowBufferRequest
+		want []string
+	}{
+		{
+			desc: "empty",
+			want: []string{"show-buffer"},
+		},
+		{
+			desc: "name",
+			give: ShowBufferRequest{Name: "buffer1"},
+			want: []string{"show-buffer", "-b", "buffer1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			blob := make([]byte, 10)
+			randRead(t, blob)
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...).Stdout(blob)
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			got, err := driver.ShowBuffer(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, blob, got)
+		})
+	}
+}
+
+func TestSetBufferArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give SetBufferRequest
+		want []string
+	}{
+		{
+			desc: "data",
+			give: SetBufferRequest{Data: "foo"},
+			want: []string{"set-buffer", "--", "foo"},
+		},
+		{
+			desc: "dash",
+			give: SetBufferRequest{Data: "-w"},
+			want: []string{"set-buffer", "--", "-w"},
+		},
+		{
+			// tmux would drop a trailing ';' as a command separator.
+			desc: "separator",
+			give: SetBufferRequest{Data: "ls -l;"},
+			want: []string{"set-buffer", "--", `ls -l\;`},
+		},
+		{
+			desc: "escaped separator",
+			give: SetBufferRequest{Data: `ls -l\;`},
+			want: []string{"set-buffer", "--", `ls -l\\;`},
+		},
+		{
+			desc: "name",
+			give: SetBufferRequest{Name: "buffer1", Data: "foo"},
+			want: []string{"set-buffer", "-b", "buffer1", "--", "foo"},
+		},
+		{
+			desc: "append",
+			give: SetBufferRequest{Data: "foo", Append: true},
+			want: []string{"set-buffer", "-a", "--", "foo"},
+		},
+		{
+			desc: "clipboard",
+			give: SetBufferRequest{Name: "buffer1", Data: "foo", Clipboard: true},
+			want: []string{"set-buffer", "-w", "-b", "buffer1", "--", "foo"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...)
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			assert.NoError(t, driver.SetBuffer(tt.give))
+		})
+	}
+}
+
+func TestSendKeysArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give SendKeysRequest
+		want []string
+	}{
+		{
+			desc: "keys",
+			give: SendKeysRequest{Keys: []string{"C-c", "Enter"}},
+			want: []string{"send-keys", "--", "C-c", "Enter"},
+		},
+		{
+			desc: "literal",
+			give: SendKeysRequest{
+				Pane:    "%42",
+				Keys:    []string{"-foo bar"},
+				Literal: true,
+			},
+			want: []string{"send-keys", "-t", "%42", "-l", "--", "-foo bar"},
+		},
+		{
+			desc: "separator",
+			give: SendKeysRequest{
+				Keys:    []string{"ls -l;", ";"},
+				Literal: true,
+			},
+			want: []string{"send-keys", "-l", "--", `ls -l\;`, `\;`},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...)
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			assert.NoError(t, driver.SendKeys(tt.give))
+		})
+	}
+}
+
 func TestSwapPaneArgs(t *testing.T) {
 	t.Parallel()
 
@@ -236,6 +561,11 @@ func TestSwapPaneArgs(t *testing.T) {
 			give: SwapPaneRequest{Source: "%43", Destination: "%42"},
 			want: []string{"swap-pane", "-t", "%42", "-s", "%43"},
 		},
+		{
+			desc: "keep zoom",
+			give: SwapPaneRequest{Source: "%43", Destination: "%42", KeepZoom: true},
+			want: []string{"swap-pane", "-t", "%42", "-s", "%43", "-Z"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -256,6 +586,76 @@ func TestSwapPaneArgs(t *testing.T) {
 	}
 }
 
+func TestDisplayPopupArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give DisplayPopupRequest
+		want []string
+	}{
+		{
+			desc: "minimal",
+			give: DisplayPopupRequest{Command: []string{"top"}},
+			want: []string{"display-popup", "'top'"},
+		},
+		{
+			desc: "everything",
+			give: DisplayPopupRequest{
+				Pane:        "%42",
+				X:           "#{popup_pane_left}",
+				Y:           "#{popup_pane_top}",
+				Width:       80,
+				Height:      24,
+				NoBorder:    true,
+				CloseOnExit: true,
+				Command:     []string{"echo", "hello world"},
+			},
+			want: []string{
+				"display-popup", "-E", "-B", "-t", "%42",
+				"-x", "#{popup_pane_left}", "-y", "#{popup_pane_top}",
+				"-w", "80", "-h", "24",
+				"'echo' 'hello world'",
+			},
+		},
+		{
+			desc: "env",
+			give: DisplayPopupRequest{
+				Env:     []string{"FOO=bar"},
+				Command: []string{"echo", "it's"},
+			},
+			want: []string{
+				"display-popup",
+				`'/usr/bin/env' 'FOO=bar' 'echo' 'it'\''s'`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...)
+
+			driver := ShellDriver{
+				run: r.Runner(),
+				log: logtest.NewLogger(t),
+			}
+			assert.NoError(t, driver.DisplayPopup(tt.give))
+		})
+	}
+}
+
+func TestDisplayPopup_noCommand(t *testing.T) {
+	t.Parallel()
+
+	driver := ShellDriver{log: logtest.NewLogger(t)}
+	err := driver.DisplayPopup(DisplayPopupRequest{})
+	assert.ErrorContains(t, err, "popup command must be set")
+}
+
 func TestResizePaneArgs(t *testing.T) {
 	t.Parallel()
 
@@ -448,6 +848,65 @@ func TestShowOptionsArgs(t *testing.T) {
 	}
 }
 
+func TestShellDriverSocket(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		socketName string
+		socketPath string
+		want       []string
+	}{
+		{
+			desc:       "name",
+			socketName: "pairing",
+			want:       []string{"-L", "pairing", "wait-for", "-S", "foo"},
+		},
+		{
+			desc:       "path",
+			socketPath: "/tmp/tmux.sock",
+			want:       []string{"-S", "/tmp/tmux.sock", "wait-for", "-S", "foo"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := newFakeRunner(t)
+			r.ExpectOutput("tmux", tt.want...)
+
+			driver := ShellDriver{
+				SocketName: tt.socketName,
+				SocketPath: tt.socketPath,
+				run:        r.Runner(),
+				log:        logtest.NewLogger(t),
+			}
+			assert.NoError(t, driver.SendSignal("foo"))
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	r := newFakeRunner(t)
+	r.ExpectOutput("tmux", "-V").Stdout([]byte("tmux 3.3a\n"))
+
+	driver := ShellDriver{
+		run: r.Runner(),
+		log: logtest.NewLogger(t),
+	}
+
+	// The version is looked up only once.
+	for i := 0; i < 2; i++ {
+		got, err := driver.Version()
+		require.NoError(t, err)
+		assert.Equal(t, Version{Major: 3, Minor: 3, Suffix: "a"}, got)
+	}
+}
+
 type fakeCall struct {
 	name string
 	args []string
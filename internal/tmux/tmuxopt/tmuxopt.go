@@ -71,6 +71,10 @@ func (l *Loader) Var(val Value, option string) {
 // We'll get the map,
 //
 //	{a: x, b: y, c: z}
+//
+// If an option matches more than one prefix, the longest prefix wins.
+// For example, with the prefixes "foo-" and "foo-item-", the option
+// "foo-item-a" is loaded only into the map for "foo-item-".
 func (l *Loader) MapVar(val MapValue, prefix string) {
 	l.init()
 
@@ -122,12 +126,16 @@ func (l *Loader) lookupValue(name string) Value {
 }
 
 func (l *Loader) lookupMapValue(name string) (key string, v MapValue) {
+	var match string // longest matching prefix
 	for prefix, val := range l.maps {
-		if strings.HasPrefix(name, prefix) {
-			return strings.TrimPrefix(name, prefix), val
+		if strings.HasPrefix(name, prefix) && (v == nil || len(prefix) > len(match)) {
+			match, v = prefix, val
 		}
 	}
-	return name, nil
+	if v == nil {
+		return name, nil
+	}
+	return strings.TrimPrefix(name, match), v
 }
 
 type stringValue string
@@ -165,6 +173,24 @@ func (v *boolValue) Set(s string) error {
 	return nil
 }
 
+type intValue int
+
+// IntVar specifies that the given option should be loaded as an integer.
+func (l *Loader) IntVar(dest *int, option string) {
+	l.init()
+
+	l.Var((*intValue)(dest), option)
+}
+
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("invalid integer value %q", s)
+	}
+	*(*int)(v) = i
+	return nil
+}
+
 // Unquote unquotes a string returned by tmux show-option.
 func Unquote(v []byte) (value string) {
 	if len(v) == 0 {
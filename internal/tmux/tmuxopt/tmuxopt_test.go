@@ -200,6 +200,50 @@ func TestLoaderBool_badBoolean(t *testing.T) {
 	require.ErrorContains(t, err, `invalid boolean value "not-a-boolean"`)
 }
 
+func TestLoaderInt(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	loader := Loader{Tmux: mockTmux}
+
+	var foo, bar int
+	loader.IntVar(&foo, "foo")
+	loader.IntVar(&bar, "bar")
+
+	mockTmux.EXPECT().
+		ShowOptions(gomock.Any()).
+		Return(unlines(
+			"foo 500",
+			"bar -1",
+		), nil)
+
+	err := loader.Load(tmux.ShowOptionsRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 500, foo)
+	assert.Equal(t, -1, bar)
+}
+
+func TestLoaderInt_badInteger(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+
+	loader := Loader{Tmux: mockTmux}
+	loader.IntVar(new(int), "foo")
+	mockTmux.EXPECT().
+		ShowOptions(gomock.Any()).
+		Return(unlines(
+			"foo lots",
+		), nil)
+
+	err := loader.Load(tmux.ShowOptionsRequest{})
+	require.ErrorContains(t, err, `invalid integer value "lots"`)
+}
+
 func TestLoaderMap(t *testing.T) {
 	t.Parallel()
 
@@ -233,6 +277,19 @@ func TestLoaderMap(t *testing.T) {
 				{"bar": "baz\tqux"},
 			},
 		},
+		{
+			desc: "overlapping prefixes",
+			give: unlines(
+				"foo-bar baz",
+				"foo-item-a x",
+				"foo-item-b y",
+			),
+			options: []string{"foo-", "foo-item-"},
+			want: []map[string]string{
+				{"bar": "baz"},
+				{"a": "x", "b": "y"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
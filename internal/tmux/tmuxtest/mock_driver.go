@@ -70,6 +70,65 @@ func (mr *MockDriverMockRecorder) DisplayMessage(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisplayMessage", reflect.TypeOf((*MockDriver)(nil).DisplayMessage), arg0)
 }
 
+// DisplayPopup mocks base method.
+func (m *MockDriver) DisplayPopup(arg0 tmux.DisplayPopupRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisplayPopup", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisplayPopup indicates an expected call of DisplayPopup.
+func (mr *MockDriverMockRecorder) DisplayPopup(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisplayPopup", reflect.TypeOf((*MockDriver)(nil).DisplayPopup), arg0)
+}
+
+// ListBuffers mocks base method.
+func (m *MockDriver) ListBuffers(arg0 tmux.ListBuffersRequest) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBuffers", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBuffers indicates an expected call of ListBuffers.
+func (mr *MockDriverMockRecorder) ListBuffers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBuffers", reflect.TypeOf((*MockDriver)(nil).ListBuffers), arg0)
+}
+
+// ListClients mocks base method.
+func (m *MockDriver) ListClients(arg0 tmux.ListClientsRequest) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClients", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClients indicates an expected call of ListClients.
+func (mr *MockDriverMockRecorder) ListClients(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClients", reflect.TypeOf((*MockDriver)(nil).ListClients), arg0)
+}
+
+// ListPanes mocks base method.
+func (m *MockDriver) ListPanes(arg0 tmux.ListPanesRequest) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPanes", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPanes indicates an expected call of ListPanes.
+func (mr *MockDriverMockRecorder) ListPanes(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPanes", reflect.TypeOf((*MockDriver)(nil).ListPanes), arg0)
+}
+
 // NewSession mocks base method.
 func (m *MockDriver) NewSession(arg0 tmux.NewSessionRequest) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -113,6 +172,20 @@ func (mr *MockDriverMockRecorder) ResizeWindow(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeWindow", reflect.TypeOf((*MockDriver)(nil).ResizeWindow), arg0)
 }
 
+// SendKeys mocks base method.
+func (m *MockDriver) SendKeys(arg0 tmux.SendKeysRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendKeys", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendKeys indicates an expected call of SendKeys.
+func (mr *MockDriverMockRecorder) SendKeys(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendKeys", reflect.TypeOf((*MockDriver)(nil).SendKeys), arg0)
+}
+
 // SendSignal mocks base method.
 func (m *MockDriver) SendSignal(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -127,6 +200,20 @@ func (mr *MockDriverMockRecorder) SendSignal(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSignal", reflect.TypeOf((*MockDriver)(nil).SendSignal), arg0)
 }
 
+// SetBuffer mocks base method.
+func (m *MockDriver) SetBuffer(arg0 tmux.SetBufferRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBuffer", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBuffer indicates an expected call of SetBuffer.
+func (mr *MockDriverMockRecorder) SetBuffer(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBuffer", reflect.TypeOf((*MockDriver)(nil).SetBuffer), arg0)
+}
+
 // SetOption mocks base method.
 func (m *MockDriver) SetOption(arg0 tmux.SetOptionRequest) error {
 	m.ctrl.T.Helper()
@@ -141,6 +228,21 @@ func (mr *MockDriverMockRecorder) SetOption(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOption", reflect.TypeOf((*MockDriver)(nil).SetOption), arg0)
 }
 
+// ShowBuffer mocks base method.
+func (m *MockDriver) ShowBuffer(arg0 tmux.ShowBufferRequest) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShowBuffer", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShowBuffer indicates an expected call of ShowBuffer.
+func (mr *MockDriverMockRecorder) ShowBuffer(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShowBuffer", reflect.TypeOf((*MockDriver)(nil).ShowBuffer), arg0)
+}
+
 // ShowOptions mocks base method.
 func (m *MockDriver) ShowOptions(arg0 tmux.ShowOptionsRequest) ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -170,6 +272,21 @@ func (mr *MockDriverMockRecorder) SwapPane(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapPane", reflect.TypeOf((*MockDriver)(nil).SwapPane), arg0)
 }
 
+// Version mocks base method.
+func (m *MockDriver) Version() (tmux.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(tmux.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Version indicates an expected call of Version.
+func (mr *MockDriverMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockDriver)(nil).Version))
+}
+
 // WaitForSignal mocks base method.
 func (m *MockDriver) WaitForSignal(arg0 string) error {
 	m.ctrl.T.Helper()
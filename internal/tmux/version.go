@@ -0,0 +1,128 @@
+package tmux
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a version of tmux.
+type Version struct {
+	Major, Minor int
+
+	// Anything after the numeric part of the version,
+	// e.g. "a" in "3.3a" or "-rc" in "3.4-rc".
+	Suffix string
+}
+
+// Versions of tmux that matter to us.
+var (
+	// MinVersion is the oldest version of tmux supported.
+	MinVersion = Version{Major: 2, Minor: 7}
+
+	// _develVersion stands in for builds of tmux that don't report a
+	// release number, like "master" and OpenBSD's tmux. These track the
+	// latest tmux so they're considered newer than any release.
+	_develVersion = Version{Major: 1<<31 - 1}
+)
+
+// ParseVersion parses the output of 'tmux -V', or just the version
+// part of it.
+//
+//	tmux 3.3a
+//	tmux next-3.4
+//	tmux master
+func ParseVersion(s string) (Version, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "tmux ")
+	s = strings.TrimPrefix(s, "next-")
+	if s == "master" || strings.HasPrefix(s, "openbsd-") {
+		v := _develVersion
+		v.Suffix = s
+		return v, nil
+	}
+
+	major, rest, ok := strings.Cut(s, ".")
+	if !ok {
+		return Version{}, fmt.Errorf("unrecognized tmux version %q", orig)
+	}
+
+	// The minor version is followed by an optional suffix.
+	minorEnd := strings.IndexFunc(rest, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if minorEnd < 0 {
+		minorEnd = len(rest)
+	}
+
+	var v Version
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil {
+		return Version{}, fmt.Errorf("unrecognized tmux version %q: bad major version: %v", orig, err)
+	}
+	if v.Minor, err = strconv.Atoi(rest[:minorEnd]); err != nil {
+		return Version{}, fmt.Errorf("unrecognized tmux version %q: bad minor version: %v", orig, err)
+	}
+	v.Suffix = rest[minorEnd:]
+	return v, nil
+}
+
+func (v Version) String() string {
+	if v.Major == _develVersion.Major {
+		return v.Suffix
+	}
+	return fmt.Sprintf("%d.%d%v", v.Major, v.Minor, v.Suffix)
+}
+
+// AtLeast reports whether this version is the same as or newer than the
+// given release. Suffixes are ignored.
+func (v Version) AtLeast(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major > o.Major
+	}
+	return v.Minor >= o.Minor
+}
+
+// Features of tmux that are available only in some versions.
+var (
+	// swap-pane -Z: keep the window zoomed.
+	_swapPaneZoom = Version{Major: 3, Minor: 1}
+
+	// load-buffer -w: send the buffer to the clipboard.
+	_loadBufferClipboard = Version{Major: 3, Minor: 2}
+
+	// display-popup -B: popups without borders.
+	_popupNoBorder = Version{Major: 3, Minor: 3}
+
+	// capture-pane -T: trim trailing positions without characters.
+	_capturePaneTrim = Version{Major: 3, Minor: 4}
+)
+
+// SupportsSwapPaneZoom reports whether swap-pane can keep the window zoomed.
+func (v Version) SupportsSwapPaneZoom() bool { return v.AtLeast(_swapPaneZoom) }
+
+// SupportsLoadBufferClipboard reports whether load-buffer can send the
+// buffer to the clipboard.
+func (v Version) SupportsLoadBufferClipboard() bool { return v.AtLeast(_loadBufferClipboard) }
+
+// SupportsPopup reports whether display-popup can show borderless popups.
+// Older versions of display-popup aren't useful to us.
+func (v Version) SupportsPopup() bool { return v.AtLeast(_popupNoBorder) }
+
+// SupportsCapturePaneTrim reports whether capture-pane can trim trailing
+// empty positions.
+func (v Version) SupportsCapturePaneTrim() bool { return v.AtLeast(_capturePaneTrim) }
+
+// CheckVersion reports the version of tmux used by the driver,
+// or an error if it's older than MinVersion.
+func CheckVersion(driver Driver) (Version, error) {
+	v, err := driver.Version()
+	if err != nil {
+		return Version{}, fmt.Errorf("determine tmux version: %v", err)
+	}
+	if !v.AtLeast(MinVersion) {
+		return v, fmt.Errorf("tmux %v is not supported: please upgrade to tmux %v or newer", v, MinVersion)
+	}
+	return v, nil
+}
@@ -0,0 +1,129 @@
+package tmux
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want Version
+		str  string
+	}{
+		{give: "tmux 2.7\n", want: Version{Major: 2, Minor: 7}, str: "2.7"},
+		{give: "tmux 3.3a", want: Version{Major: 3, Minor: 3, Suffix: "a"}, str: "3.3a"},
+		{give: "tmux 3.4-rc", want: Version{Major: 3, Minor: 4, Suffix: "-rc"}, str: "3.4-rc"},
+		{give: "tmux next-3.5", want: Version{Major: 3, Minor: 5}, str: "3.5"},
+		{give: "3.1", want: Version{Major: 3, Minor: 1}, str: "3.1"},
+		{give: "tmux master", want: Version{Major: _develVersion.Major, Suffix: "master"}, str: "master"},
+		{give: "tmux openbsd-7.4", want: Version{Major: _develVersion.Major, Suffix: "openbsd-7.4"}, str: "openbsd-7.4"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseVersion(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.str, got.String())
+		})
+	}
+}
+
+func TestParseVersion_errors(t *testing.T) {
+	t.Parallel()
+
+	for _, give := range []string{"", "tmux", "tmux 3", "tmux x.1", "tmux 3.x"} {
+		_, err := ParseVersion(give)
+		assert.ErrorContains(t, err, "unrecognized tmux version", "input %q", give)
+	}
+}
+
+func TestVersionFeatures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+
+		swapPaneZoom        bool
+		loadBufferClipboard bool
+		popup               bool
+		capturePaneTrim     bool
+	}{
+		{give: "2.7"},
+		{give: "3.0a"},
+		{give: "3.1", swapPaneZoom: true},
+		{give: "3.2a", swapPaneZoom: true, loadBufferClipboard: true},
+		{give: "3.3", swapPaneZoom: true, loadBufferClipboard: true, popup: true},
+		{
+			give:         "3.4",
+			swapPaneZoom: true, loadBufferClipboard: true, popup: true,
+			capturePaneTrim: true,
+		},
+		{
+			give:         "master",
+			swapPaneZoom: true, loadBufferClipboard: true, popup: true,
+			capturePaneTrim: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := ParseVersion(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.swapPaneZoom, v.SupportsSwapPaneZoom(), "swap-pane -Z")
+			assert.Equal(t, tt.loadBufferClipboard, v.SupportsLoadBufferClipboard(), "load-buffer -w")
+			assert.Equal(t, tt.popup, v.SupportsPopup(), "display-popup -B")
+			assert.Equal(t, tt.capturePaneTrim, v.SupportsCapturePaneTrim(), "capture-pane -T")
+		})
+	}
+}
+
+type versionDriver struct {
+	Driver
+
+	version Version
+	err     error
+}
+
+func (d *versionDriver) Version() (Version, error) {
+	return d.version, d.err
+}
+
+func TestCheckVersion(t *testing.T) {
+	t.Parallel()
+
+	t.Run("supported", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := CheckVersion(&versionDriver{version: Version{Major: 3, Minor: 2}})
+		require.NoError(t, err)
+		assert.Equal(t, Version{Major: 3, Minor: 2}, v)
+	})
+
+	t.Run("too old", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := CheckVersion(&versionDriver{version: Version{Major: 2, Minor: 6}})
+		assert.ErrorContains(t, err,
+			"tmux 2.6 is not supported: please upgrade to tmux 2.7 or newer")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := CheckVersion(&versionDriver{err: errors.New("great sadness")})
+		assert.ErrorContains(t, err, "determine tmux version: great sadness")
+	})
+}
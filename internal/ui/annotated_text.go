@@ -3,7 +3,9 @@ package ui
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v3"
 )
@@ -49,7 +51,7 @@ type AnnotatedText struct {
 	anns []TextAnnotation // sorted by offset
 }
 
-var _ Widget = (*AnnotatedText)(nil)
+var _ Tall = (*AnnotatedText)(nil)
 
 // SetAnnotations changes the annotations for an AnnotatedText. Offsets MUST
 // not overlap.
@@ -81,36 +83,54 @@ func (at *AnnotatedText) Draw(view View) {
 			continue
 		}
 
-		// TODO: The way this is set up, an overlay annotation
-		// can undo the row increment that would happen from a newline.
-		// This is probably not the best internal representation.
-
 		pos = DrawText(at.Text[lastIdx:ann.offset()], at.Style, view, pos)
 
 		var (
 			style tcell.Style
 			text  string
+			end   = ann.offset() + ann.length()
 		)
 		switch ann := ann.(type) {
 		case StyleTextAnnotation:
 			style = ann.Style
-			text = at.Text[ann.Offset : ann.Offset+ann.Length]
+			text = at.Text[ann.Offset:end]
 
 		case OverlayTextAnnotation:
 			style = ann.Style
 			text = ann.Overlay
 
+			// Cut the overlay short at the end of the line so that
+			// the newline under it is still drawn.
+			covered := at.Text[ann.Offset:min(end, len(at.Text))]
+			if i := strings.IndexByte(covered, '\n'); i >= 0 {
+				for i > 0 && !utf8.RuneStart(text[i]) {
+					i--
+				}
+				text = text[:i]
+				end = ann.Offset + i
+			}
+
 		default:
 			panic(fmt.Sprintf("unknown annotation %#v", ann))
 		}
 
 		pos = DrawText(text, style, view, pos)
-		lastIdx = ann.offset() + ann.length()
+		lastIdx = end
 	}
 
 	DrawText(at.Text[lastIdx:], at.Style, view, pos)
 }
 
+// Height reports the number of rows needed to draw the text in full on a
+// view of the given width.
+func (at *AnnotatedText) Height(width int) int {
+	pos := DrawText(at.Text, at.Style, measureView{width: width}, Pos{})
+	if pos.X > 0 {
+		pos.Y++ // last line doesn't end with a newline
+	}
+	return pos.Y
+}
+
 // HandleEvent returns false.
 func (at *AnnotatedText) HandleEvent(tcell.Event) bool {
 	return false
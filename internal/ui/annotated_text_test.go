@@ -136,6 +136,24 @@ func TestAnnotatedText(t *testing.T) {
 		)
 	})
 
+	t.Run("overlay/end of line", func(t *testing.T) {
+		defer scr.Clear()
+		defer at.SetAnnotations()
+
+		at.SetAnnotations(
+			OverlayTextAnnotation{Overlay: "xy", Offset: 2, Style: highlighted},
+		)
+
+		at.Draw(scr)
+		scr.Show()
+
+		matchScreen(t,
+			n('f'), n('o'), h('x'),
+			n('b'), n('a'), n('r'),
+			n('b'), n('a'), n('z'),
+		)
+	})
+
 	t.Run("overlapping", func(t *testing.T) {
 		defer scr.Clear()
 		defer at.SetAnnotations()
@@ -168,3 +186,31 @@ func TestAnnotatedText(t *testing.T) {
 		})
 	})
 }
+
+func TestAnnotatedTextHeight(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		text  string
+		width int
+		want  int
+	}{
+		{desc: "empty", width: 10, want: 0},
+		{desc: "single line", text: "foo", width: 10, want: 1},
+		{desc: "trailing newline", text: "foo\nbar\n", width: 10, want: 2},
+		{desc: "blank lines", text: "foo\n\n\n", width: 10, want: 3},
+		{desc: "wrapped", text: "foobar\nbaz\n", width: 3, want: 3},
+		{desc: "wide chars", text: "世界世界\n", width: 4, want: 2},
+		{desc: "wide char at the edge", text: "abc世\n", width: 4, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			at := AnnotatedText{Text: tt.text}
+			assert.Equal(t, tt.want, at.Height(tt.width))
+		})
+	}
+}
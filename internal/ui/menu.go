@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/rivo/uniseg"
+)
+
+// MenuItem is an entry in a Menu.
+type MenuItem struct {
+	// Key that chooses this item. This is a single character.
+	Key string
+
+	// Label describes the item.
+	Label string
+}
+
+// Menu shows a list of items on top of another widget, each with a key that
+// chooses it.
+//
+// The menu is hidden until Show is called. While it's hidden, it draws only
+// the child, and passes all events on to it.
+type Menu struct {
+	Child Widget
+
+	Style    tcell.Style // style of the menu
+	KeyStyle tcell.Style // style of the keys of items
+
+	mu     sync.Mutex
+	title  string
+	items  []MenuItem
+	choose func(MenuItem)
+}
+
+var _ Widget = (*Menu)(nil)
+
+// Show shows the menu with the given title and items. choose is called with
+// an item when its key is pressed, or with the first item when Enter is
+// pressed. The menu is hidden after that.
+func (m *Menu) Show(title string, items []MenuItem, choose func(MenuItem)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.title = title
+	m.items = items
+	m.choose = choose
+}
+
+// Visible reports whether the menu is being shown.
+func (m *Menu) Visible() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items) > 0
+}
+
+// Draw draws the child, and the menu in the top right corner of the view if
+// it's being shown.
+func (m *Menu) Draw(view View) {
+	m.Child.Draw(view)
+
+	m.mu.Lock()
+	title, items := m.title, m.items
+	m.mu.Unlock()
+	if len(items) == 0 {
+		return
+	}
+
+	// Each row has one space of padding on either side.
+	//
+	//  title
+	//  k label
+	width := uniseg.StringWidth(title)
+	for _, item := range items {
+		width = max(width, uniseg.StringWidth(item.Key)+1+uniseg.StringWidth(item.Label))
+	}
+	width += 2
+
+	w, _ := view.Size()
+	width = min(width, w)
+	left := w - width
+
+	drawMenuRow(view, Pos{X: left, Y: 0}, width, m.Style, title)
+	for i, item := range items {
+		pos := Pos{X: left, Y: i + 1}
+		drawMenuRow(view, pos, width, m.Style, "")
+		pos.X++
+		pos = drawMenuText(view, pos, left+width-1, m.KeyStyle, item.Key)
+		pos.X++
+		drawMenuText(view, pos, left+width-1, m.Style, item.Label)
+	}
+}
+
+// drawMenuRow fills a row of the menu with the given style, and draws text
+// on it after one space of padding.
+func drawMenuRow(view View, pos Pos, width int, style tcell.Style, text string) {
+	for x := pos.X; x < pos.X+width; x++ {
+		view.Put(x, pos.Y, " ", style)
+	}
+	drawMenuText(view, Pos{X: pos.X + 1, Y: pos.Y}, pos.X+width-1, style, text)
+}
+
+// drawMenuText draws text on a single row, clipping it at the column right.
+func drawMenuText(view View, pos Pos, right int, style tcell.Style, text string) Pos {
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		if pos.X+g.Width() > right {
+			break
+		}
+		_, width := view.Put(pos.X, pos.Y, g.Str(), style)
+		pos.X += width
+	}
+	return pos
+}
+
+// HandleEvent chooses an item if the menu is being shown, and passes events
+// to the child otherwise.
+//
+// While the menu is shown, keys that don't choose an item are ignored.
+func (m *Menu) HandleEvent(ev tcell.Event) (handled bool) {
+	m.mu.Lock()
+	items, choose := m.items, m.choose
+	m.mu.Unlock()
+	if len(items) == 0 {
+		return m.Child.HandleEvent(ev)
+	}
+
+	ek, ok := ev.(*tcell.EventKey)
+	if !ok {
+		return false
+	}
+
+	var (
+		item  MenuItem
+		found bool
+	)
+	switch ek.Key() {
+	case tcell.KeyEnter:
+		item, found = items[0], true
+	case tcell.KeyRune:
+		for _, it := range items {
+			if it.Key == ek.Str() {
+				item, found = it, true
+				break
+			}
+		}
+	}
+	if !found {
+		return true
+	}
+
+	m.mu.Lock()
+	m.title, m.items, m.choose = "", nil, nil
+	m.mu.Unlock()
+
+	choose(item)
+	return true
+}
@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMenu(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	child := NewMockWidget(mockCtrl)
+	scr := newRenderScreen(12, 4)
+
+	m := Menu{Child: child}
+	ev := tcell.NewEventKey(tcell.KeyRune, "o", tcell.ModNone)
+
+	// Hidden: everything goes to the child.
+	child.EXPECT().Draw(scr)
+	child.EXPECT().HandleEvent(ev).Return(true)
+	m.Draw(scr)
+	assert.True(t, m.HandleEvent(ev))
+	assert.False(t, m.Visible())
+
+	var chosen []MenuItem
+	choose := func(item MenuItem) { chosen = append(chosen, item) }
+	items := []MenuItem{
+		{Key: "c", Label: "copy"},
+		{Key: "o", Label: "open"},
+	}
+	m.Show("deadbeef", items, choose)
+	assert.True(t, m.Visible())
+
+	child.EXPECT().Draw(scr)
+	m.Draw(scr)
+	assert.Equal(t, []string{
+		// The first two columns belong to the child.
+		" deadbeef ",
+		" c copy   ",
+		" o open   ",
+		"",
+	}, screenRows(scr))
+
+	assert.True(t,
+		m.HandleEvent(tcell.NewEventKey(tcell.KeyRune, "x", tcell.ModNone)),
+		"other keys must be swallowed")
+	assert.Empty(t, chosen)
+
+	assert.True(t, m.HandleEvent(ev))
+	require.Equal(t, []MenuItem{items[1]}, chosen)
+	assert.False(t, m.Visible(), "menu must be hidden after a choice")
+
+	m.Show("deadbeef", items, choose)
+	assert.True(t, m.HandleEvent(tcell.NewEventKey(tcell.KeyEnter, "", tcell.ModNone)))
+	assert.Equal(t, []MenuItem{items[1], items[0]}, chosen,
+		"enter must choose the first item")
+}
+
+func TestMenu_clipped(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	child := NewMockWidget(mockCtrl)
+	scr := newRenderScreen(6, 2)
+
+	m := Menu{Child: child}
+	m.Show("a long title", []MenuItem{{Key: "c", Label: "copy"}}, func(MenuItem) {})
+
+	child.EXPECT().Draw(scr)
+	m.Draw(scr)
+	assert.Equal(t, []string{
+		" a lo ",
+		" c co ",
+	}, screenRows(scr))
+}
@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"math"
+	"sync"
+
+	"github.com/gdamore/tcell/v3"
+	"github.com/rivo/uniseg"
+)
+
+// Tall is a widget that may be taller than the view it's drawn on.
+type Tall interface {
+	Widget
+
+	// Height reports the number of rows needed to draw the widget in full
+	// on a view of the given width.
+	Height(width int) int
+}
+
+// ScrollView draws a window of rows of a widget that may be taller than the
+// view. It starts at the bottom of the widget unless FromTop is set.
+//
+// It scrolls by a page with PageUp and PageDown, and by half a page with
+// Ctrl-U and Ctrl-D. All other events are passed on to the widget.
+type ScrollView struct {
+	Child Tall
+
+	// Whether to start at the top of the widget instead of the bottom.
+	FromTop bool
+
+	mu     sync.Mutex
+	scroll int // rows scrolled away from the edge we started at
+	height int // height of the view when it was last drawn
+}
+
+var _ Widget = (*ScrollView)(nil)
+
+// Draw draws the visible rows of the child widget onto the view.
+func (sv *ScrollView) Draw(view View) {
+	w, h := view.Size()
+	rows := sv.Child.Height(w)
+
+	sv.mu.Lock()
+	sv.height = h
+	sv.scroll = clampScroll(sv.scroll, rows, h)
+	top := max(rows-h, 0) - sv.scroll
+	if sv.FromTop {
+		top = sv.scroll
+	}
+	sv.mu.Unlock()
+
+	sv.Child.Draw(&offsetView{View: view, dy: top})
+}
+
+// HandleEvent scrolls the view for scroll keys, and passes all other events
+// to the child widget.
+func (sv *ScrollView) HandleEvent(ev tcell.Event) (handled bool) {
+	if ek, ok := ev.(*tcell.EventKey); ok {
+		sv.mu.Lock()
+		page := sv.height
+		sv.mu.Unlock()
+
+		var delta int
+		switch ek.Key() {
+		case tcell.KeyPgUp:
+			delta = page
+		case tcell.KeyPgDn:
+			delta = -page
+		case tcell.KeyCtrlU:
+			delta = max(page/2, 1)
+		case tcell.KeyCtrlD:
+			delta = -max(page/2, 1)
+		}
+
+		if sv.FromTop {
+			// Scrolling up moves towards the edge we started at.
+			delta = -delta
+		}
+
+		if delta != 0 {
+			// The scroll position is clamped to the height of the
+			// child when it's drawn next.
+			sv.mu.Lock()
+			sv.scroll = max(sv.scroll+delta, 0)
+			sv.mu.Unlock()
+			return true
+		}
+	}
+
+	return sv.Child.HandleEvent(ev)
+}
+
+// clampScroll limits the number of rows scrolled away from one edge of a
+// widget with the given number of rows inside a view of the given height.
+func clampScroll(scroll, rows, height int) int {
+	return min(max(scroll, 0), max(rows-height, 0))
+}
+
+// offsetView is a View that starts dy rows down from the top of another view.
+// Rows above that are hidden.
+type offsetView struct {
+	View
+
+	dy int
+}
+
+func (v *offsetView) Size() (int, int) {
+	w, h := v.View.Size()
+	return w, h + v.dy
+}
+
+func (v *offsetView) Put(x, y int, str string, style tcell.Style) (string, int) {
+	if y < v.dy {
+		return skipGrapheme(str)
+	}
+	return v.View.Put(x, y-v.dy, str, style)
+}
+
+// measureView is a View of unlimited height that doesn't draw anything.
+// Use it to find how many rows text needs.
+type measureView struct{ width int }
+
+func (v measureView) Size() (int, int) {
+	return v.width, math.MaxInt
+}
+
+func (measureView) Put(_, _ int, str string, _ tcell.Style) (string, int) {
+	return skipGrapheme(str)
+}
+
+// skipGrapheme skips past the first grapheme cluster in str, reporting the
+// rest of the string and the width of the grapheme as View.Put would.
+func skipGrapheme(str string) (string, int) {
+	_, rest, width, _ := uniseg.FirstGraphemeClusterInString(str, -1)
+	return rest, width
+}
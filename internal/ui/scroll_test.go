@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestScrollView(t *testing.T) {
+	t.Parallel()
+
+	key := func(k tcell.Key) tcell.Event {
+		return tcell.NewEventKey(k, "", tcell.ModNone)
+	}
+
+	tests := []struct {
+		desc  string
+		text  string
+		width int // defaults to 1
+		top   bool
+		keys  []tcell.Event
+		want  []string // rows of the screen
+	}{
+		{
+			desc: "fits",
+			text: "a\nb\n",
+			want: []string{"a", "b", "", ""},
+		},
+		{
+			desc: "starts at the bottom",
+			text: "a\nb\nc\nd\ne\nf\n",
+			want: []string{"c", "d", "e", "f"},
+		},
+		{
+			desc: "page up",
+			text: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+			keys: []tcell.Event{key(tcell.KeyPgUp)},
+			want: []string{"c", "d", "e", "f"},
+		},
+		{
+			desc: "page up past the top",
+			text: "a\nb\nc\nd\ne\nf\n",
+			keys: []tcell.Event{key(tcell.KeyPgUp), key(tcell.KeyPgUp)},
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			desc: "page down past the bottom",
+			text: "a\nb\nc\nd\ne\nf\n",
+			keys: []tcell.Event{key(tcell.KeyPgUp), key(tcell.KeyPgDn), key(tcell.KeyPgDn)},
+			want: []string{"c", "d", "e", "f"},
+		},
+		{
+			desc: "half page",
+			text: "a\nb\nc\nd\ne\nf\ng\nh\n",
+			keys: []tcell.Event{key(tcell.KeyCtrlU), key(tcell.KeyCtrlU), key(tcell.KeyCtrlD)},
+			want: []string{"c", "d", "e", "f"},
+		},
+		{
+			desc:  "wrapped lines",
+			text:  "a\nbcdef\ng\n",
+			width: 2,
+			want:  []string{"bc", "de", "f", "g"},
+		},
+		{
+			desc: "from top",
+			text: "a\nb\nc\nd\ne\nf\n",
+			top:  true,
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			desc: "from top/page down",
+			text: "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+			top:  true,
+			keys: []tcell.Event{key(tcell.KeyPgDn)},
+			want: []string{"e", "f", "g", "h"},
+		},
+		{
+			desc: "from top/half page up past the top",
+			text: "a\nb\nc\nd\ne\nf\n",
+			top:  true,
+			keys: []tcell.Event{key(tcell.KeyCtrlD), key(tcell.KeyCtrlU), key(tcell.KeyCtrlU)},
+			want: []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			width := tt.width
+			if width == 0 {
+				width = 1
+			}
+
+			scr := newRenderScreen(width, 4)
+			sv := ScrollView{
+				Child:   &AnnotatedText{Text: tt.text},
+				FromTop: tt.top,
+			}
+			sv.Draw(scr)
+			for _, ev := range tt.keys {
+				assert.True(t, sv.HandleEvent(ev), "event must be handled")
+				scr.Clear()
+				sv.Draw(scr)
+			}
+
+			assert.Equal(t, tt.want, screenRows(scr))
+		})
+	}
+}
+
+func TestScrollView_passesEvents(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	child := NewMockWidget(mockCtrl)
+	ev := tcell.NewEventKey(tcell.KeyRune, "a", tcell.ModNone)
+	child.EXPECT().HandleEvent(ev).Return(true)
+
+	sv := ScrollView{Child: tallWidget{Widget: child}}
+	assert.True(t, sv.HandleEvent(ev))
+}
+
+type tallWidget struct{ Widget }
+
+func (tallWidget) Height(int) int { return 0 }
+
+// screenRows returns the text on each row of the screen.
+func screenRows(scr *renderScreen) []string {
+	rows := make([]string, scr.h)
+	for y := range scr.h {
+		var sb strings.Builder
+		for x := range scr.w {
+			str, _, _ := scr.Get(x, y)
+			sb.WriteString(str)
+		}
+		rows[y] = sb.String()
+	}
+	return rows
+}
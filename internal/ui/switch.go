@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v3"
+)
+
+// Switch shows one of several widgets at a time. It starts with the first
+// widget, and moves to the next one every time Key is pressed, wrapping
+// around after the last one.
+//
+// All other events are passed on to the widget being shown.
+type Switch struct {
+	Key     tcell.Key
+	Widgets []Widget
+
+	mu      sync.Mutex
+	current int // index in Widgets
+}
+
+var _ Widget = (*Switch)(nil)
+
+// Draw draws the widget being shown onto the view.
+func (s *Switch) Draw(view View) {
+	s.Widget().Draw(view)
+}
+
+// HandleEvent switches to the next widget for Key, and passes all other events
+// to the widget being shown.
+func (s *Switch) HandleEvent(ev tcell.Event) (handled bool) {
+	if ek, ok := ev.(*tcell.EventKey); ok && ek.Key() == s.Key {
+		s.mu.Lock()
+		s.current = (s.current + 1) % len(s.Widgets)
+		s.mu.Unlock()
+		return true
+	}
+
+	return s.Widget().HandleEvent(ev)
+}
+
+// Widget returns the widget being shown.
+func (s *Switch) Widget() Widget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Widgets[s.current]
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	a := NewMockWidget(mockCtrl)
+	b := NewMockWidget(mockCtrl)
+
+	s := Switch{
+		Key:     tcell.KeyCtrlL,
+		Widgets: []Widget{a, b},
+	}
+	toggle := tcell.NewEventKey(tcell.KeyCtrlL, "", tcell.ModNone)
+	ev := tcell.NewEventKey(tcell.KeyRune, "x", tcell.ModNone)
+	scr := newRenderScreen(1, 1)
+
+	a.EXPECT().Draw(scr)
+	a.EXPECT().HandleEvent(ev).Return(true)
+	s.Draw(scr)
+	assert.True(t, s.HandleEvent(ev))
+	assert.Equal(t, a, s.Widget())
+
+	assert.True(t, s.HandleEvent(toggle), "switch key must be handled")
+	b.EXPECT().Draw(scr)
+	b.EXPECT().HandleEvent(ev).Return(false)
+	s.Draw(scr)
+	assert.False(t, s.HandleEvent(ev))
+
+	assert.True(t, s.HandleEvent(toggle), "switch key must be handled")
+	assert.Equal(t, a, s.Widget(), "must wrap around")
+}
@@ -12,6 +12,7 @@ import (
 //	pos = DrawText("foo\nb", style, view, pos)
 //	pos = DrawText("ar", style, view, pos)
 //
+// Text wraps to the next row at the right edge of the view.
 // Text that bleeds outside the bounds of the view is ignored.
 func DrawText(s string, style tcell.Style, view View, pos Pos) Pos {
 	if len(s) == 0 {
@@ -22,7 +23,7 @@ func DrawText(s string, style tcell.Style, view View, pos Pos) Pos {
 	g := uniseg.NewGraphemes(s)
 	for g.Next() {
 		s := g.Str()
-		if pos.X >= w || s == "\n" {
+		if s == "\n" || wraps(pos.X, g.Width(), w) {
 			pos.Y++
 			pos.X = 0
 		}
@@ -39,3 +40,35 @@ func DrawText(s string, style tcell.Style, view View, pos Pos) Pos {
 
 	return pos
 }
+
+// WrapOffsets reports the offsets in s at which DrawText moves to the next
+// row of a view with the given width without a newline in s.
+func WrapOffsets(s string, width int) []int {
+	var (
+		offsets []int
+		x       int
+	)
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		if g.Str() == "\n" {
+			x = 0
+			continue
+		}
+
+		if wraps(x, g.Width(), width) {
+			start, _ := g.Positions()
+			offsets = append(offsets, start)
+			x = 0
+		}
+		x += g.Width()
+	}
+	return offsets
+}
+
+// wraps reports whether a grapheme of the given width that would be drawn at
+// column x of a view with width w goes on the next row instead.
+func wraps(x, width, w int) bool {
+	// Like terminals, move wide characters that don't fit in the rest of
+	// the row to the next one.
+	return x >= w || (x > 0 && x+width > w)
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 
 	tcell "github.com/gdamore/tcell/v3"
@@ -59,6 +60,18 @@ func TestDrawText(t *testing.T) {
 			text: "a\x00b",
 			want: Pos{2, 0},
 		},
+		{
+			desc: "wide char/at the edge",
+			w:    4,
+			text: "abc世d",
+			want: Pos{3, 1},
+		},
+		{
+			desc: "wide char/wider than view",
+			w:    1,
+			text: "世d",
+			want: Pos{1, 1},
+		},
 		{
 			desc: "combining rune",
 			text: string([]rune{0x1f3f3, 0xfe0f, 0x200d, 0x1f308}), // 🏳️‍🌈
@@ -87,3 +100,38 @@ func TestDrawText(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapOffsets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		text  string
+		width int
+		want  []int
+	}{
+		{desc: "empty", width: 4},
+		{desc: "fits", text: "abcd\nefgh\n", width: 4},
+		{desc: "wraps", text: "abcdefghij\n", width: 4, want: []int{4, 8}},
+		{desc: "after newline", text: "ab\ncdefg", width: 4, want: []int{7}},
+		{desc: "wide char at the edge", text: "abc世d", width: 4, want: []int{3}},
+		{desc: "wide chars", text: "世界世界", width: 4, want: []int{6}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := WrapOffsets(tt.text, tt.width)
+			assert.Equal(t, tt.want, got)
+
+			// Every offset must start a new row in DrawText
+			// without a newline.
+			scr := newRenderScreen(tt.width, 10)
+			rows := DrawText(tt.text, tcell.StyleDefault, scr, Pos{}).Y
+			newlines := strings.Count(tt.text, "\n")
+			assert.Equal(t, rows, newlines+len(got), "rows drawn")
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/abhinav/tmux-fastcopy/internal/must"
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/gdamore/tcell/v3/vt"
+)
+
+// _namedKeys maps the names of keys that may be used inside angle brackets
+// in a key sequence to their tcell keys. Names are case-insensitive.
+var _namedKeys = map[string]tcell.Key{
+	"tab":       tcell.KeyTab,
+	"enter":     tcell.KeyEnter,
+	"cr":        tcell.KeyEnter,
+	"esc":       tcell.KeyEscape,
+	"escape":    tcell.KeyEscape,
+	"bs":        tcell.KeyBackspace,
+	"backspace": tcell.KeyBackspace,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"pageup":    tcell.KeyPgUp,
+	"pgup":      tcell.KeyPgUp,
+	"pagedown":  tcell.KeyPgDn,
+	"pgdn":      tcell.KeyPgDn,
+}
+
+// keySequence is a sequence of keys to feed into the UI instead of reading
+// them from the terminal.
+//
+// Characters in the sequence are typed as-is, and uppercase letters are
+// typed with shift. Other keys are specified by name inside angle brackets,
+// e.g. "<Tab>" or "<Enter>". "<S-x>" types x with shift, "<C-x>" types x
+// with control, "<M-x>" types x with alt, "<Space>" types a space, and
+// "<lt>" types a literal "<".
+type keySequence string
+
+func (ks *keySequence) String() string {
+	return string(*ks)
+}
+
+func (ks *keySequence) Set(v string) error {
+	if _, err := parseKeys(v); err != nil {
+		return err
+	}
+	*ks = keySequence(v)
+	return nil
+}
+
+// IsSet reports whether a key sequence was specified.
+func (ks keySequence) IsSet() bool {
+	return len(ks) > 0
+}
+
+// Events returns new key events for this sequence.
+func (ks keySequence) Events() []*tcell.EventKey {
+	events, err := parseKeys(string(ks))
+	must.NotErrorf(err, "invalid key sequence %q", string(ks))
+	return events
+}
+
+func parseKeys(s string) ([]*tcell.EventKey, error) {
+	var events []*tcell.EventKey
+	for len(s) > 0 {
+		if s[0] != '<' {
+			r, n := utf8.DecodeRuneInString(s)
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, string(r), tcell.ModNone))
+			s = s[n:]
+			continue
+		}
+
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated key name in %q: use <lt> for a literal '<'", s)
+		}
+
+		ev, err := parseNamedKey(s[1:end])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+		s = s[end+1:]
+	}
+	return events, nil
+}
+
+func parseNamedKey(name string) (*tcell.EventKey, error) {
+	switch lower := strings.ToLower(name); lower {
+	case "lt":
+		return tcell.NewEventKey(tcell.KeyRune, "<", tcell.ModNone), nil
+	case "space":
+		return tcell.NewEventKey(tcell.KeyRune, " ", tcell.ModNone), nil
+	default:
+		if k, ok := _namedKeys[lower]; ok {
+			return tcell.NewEventKey(k, "", tcell.ModNone), nil
+		}
+
+		// tcell doesn't report shift for rune keys,
+		// so shifted letters are typed in uppercase instead.
+		if rest, ok := strings.CutPrefix(lower, "s-"); ok && utf8.RuneCountInString(rest) == 1 {
+			return tcell.NewEventKey(tcell.KeyRune, strings.ToUpper(rest), tcell.ModNone), nil
+		}
+		if rest, ok := strings.CutPrefix(lower, "c-"); ok && len(rest) == 1 && 'a' <= rest[0] && rest[0] <= 'z' {
+			return tcell.NewEventKey(tcell.KeyRune, rest, tcell.ModCtrl), nil
+		}
+		if rest, ok := strings.CutPrefix(lower, "m-"); ok && utf8.RuneCountInString(rest) == 1 {
+			return tcell.NewEventKey(tcell.KeyRune, rest, tcell.ModAlt), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key <%v>", name)
+}
+
+// newHeadlessScreen builds a screen of the given size backed by a simulated
+// terminal instead of a real one.
+func newHeadlessScreen(width, height int) (tcell.Screen, error) {
+	term := vt.NewMockTerm(vt.MockOptSize{X: vt.Col(width), Y: vt.Row(height)})
+	return tcell.NewTerminfoScreenFromTty(term)
+}
+
+// postKeys posts the given key events to the screen's event queue in order,
+// followed by an escape to close the UI if the keys didn't already do so.
+//
+// It stops early if stop is closed.
+func postKeys(screen tcell.Screen, events []*tcell.EventKey, stop <-chan struct{}) {
+	post := func(ev tcell.Event) bool {
+		select {
+		case screen.EventQ() <- ev:
+			return true
+		case <-stop:
+			return false
+		}
+	}
+
+	for _, ev := range events {
+		if !post(ev) {
+			return
+		}
+	}
+	post(tcell.NewEventKey(tcell.KeyEscape, "", tcell.ModNone))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+
+	type key struct {
+		Key tcell.Key
+		Str string
+		Mod tcell.ModMask
+	}
+
+	tests := []struct {
+		desc    string
+		give    string
+		want    []key
+		wantErr string
+	}{
+		{
+			desc: "runes",
+			give: "ab",
+			want: []key{
+				{tcell.KeyRune, "a", tcell.ModNone},
+				{tcell.KeyRune, "b", tcell.ModNone},
+			},
+		},
+		{
+			desc: "uppercase",
+			give: "A",
+			want: []key{{tcell.KeyRune, "A", tcell.ModNone}},
+		},
+		{
+			desc: "named",
+			give: "<Tab>a<enter><ESC><bs>",
+			want: []key{
+				{tcell.KeyTab, "", tcell.ModNone},
+				{tcell.KeyRune, "a", tcell.ModNone},
+				{tcell.KeyEnter, "", tcell.ModNone},
+				{tcell.KeyEscape, "", tcell.ModNone},
+				{tcell.KeyBackspace, "", tcell.ModNone},
+			},
+		},
+		{
+			desc: "special runes",
+			give: "<lt><Space>",
+			want: []key{
+				{tcell.KeyRune, "<", tcell.ModNone},
+				{tcell.KeyRune, " ", tcell.ModNone},
+			},
+		},
+		{
+			desc: "shift",
+			give: "<S-a>",
+			want: []key{{tcell.KeyRune, "A", tcell.ModNone}},
+		},
+		{
+			desc: "control",
+			give: "<C-u><c-D>",
+			want: []key{
+				{tcell.KeyCtrlU, "", tcell.ModCtrl},
+				{tcell.KeyCtrlD, "", tcell.ModCtrl},
+			},
+		},
+		{
+			desc: "alt",
+			give: "<M-a><m-,>",
+			want: []key{
+				{tcell.KeyRune, "a", tcell.ModAlt},
+				{tcell.KeyRune, ",", tcell.ModAlt},
+			},
+		},
+		{
+			desc:    "control/not a letter",
+			give:    "<C-1>",
+			wantErr: "unknown key <C-1>",
+		},
+		{
+			desc: "unicode",
+			give: "é",
+			want: []key{{tcell.KeyRune, "é", tcell.ModNone}},
+		},
+		{
+			desc:    "unterminated",
+			give:    "a<Tab",
+			wantErr: `unterminated key name in "<Tab"`,
+		},
+		{
+			desc:    "unknown",
+			give:    "<F13>",
+			wantErr: "unknown key <F13>",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			events, err := parseKeys(tt.give)
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]key, len(events))
+			for i, ev := range events {
+				got[i] = key{ev.Key(), ev.Str(), ev.Modifiers()}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
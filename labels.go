@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/labelcache"
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+)
+
+const _labelCacheFile = "labels.json"
+
+// labelCacheScope specifies whether labels assigned to text are remembered
+// between invocations, and if so, how they're shared.
+type labelCacheScope string
+
+const (
+	// Labels are not remembered.
+	labelCacheOff labelCacheScope = ""
+
+	// Labels are remembered separately for each pane.
+	labelCachePane labelCacheScope = "pane"
+
+	// Labels are shared between all panes.
+	labelCacheGlobal labelCacheScope = "global"
+)
+
+func (s *labelCacheScope) String() string {
+	return string(*s)
+}
+
+func (s *labelCacheScope) Set(v string) error {
+	switch labelCacheScope(v) {
+	case labelCacheOff, "off":
+		*s = labelCacheOff
+	case labelCachePane, labelCacheGlobal:
+		*s = labelCacheScope(v)
+	default:
+		return fmt.Errorf("unknown label cache scope %q: must be one of off, pane, or global", v)
+	}
+	return nil
+}
+
+// labelStrategy specifies how labels are generated for text on the screen.
+type labelStrategy string
+
+const (
+	// Shortest labels for the most frequent text.
+	labelStrategyHuffman labelStrategy = "huffman"
+
+	// Consecutive letters of labels alternate between the two halves of
+	// the alphabet.
+	labelStrategyAlternating labelStrategy = "alternating"
+)
+
+func (s *labelStrategy) String() string {
+	return string(*s)
+}
+
+func (s *labelStrategy) Set(v string) error {
+	switch labelStrategy(v) {
+	case labelStrategyHuffman, labelStrategyAlternating:
+		*s = labelStrategy(v)
+	default:
+		return fmt.Errorf("unknown label strategy %q: must be one of huffman or alternating", v)
+	}
+	return nil
+}
+
+// Strategy returns the fastcopy label strategy for this value.
+// The zero value maps to the default strategy.
+func (s labelStrategy) Strategy() fastcopy.LabelStrategy {
+	if s == labelStrategyAlternating {
+		return fastcopy.AlternatingLabels
+	}
+	return fastcopy.HuffmanLabels
+}
+
+// labelMemory remembers labels assigned to text in a pane.
+type labelMemory struct {
+	Log   *log.Logger
+	Cache *labelcache.Cache
+	Path  string // path to the cache file
+	Key   string // key in the cache
+}
+
+// openLabelMemory loads the label cache for the given pane.
+// It returns nil if label caching is disabled or unavailable.
+func openLabelMemory(logger *log.Logger, scope labelCacheScope, stateDir, paneID string) *labelMemory {
+	if scope == labelCacheOff {
+		return nil
+	}
+
+	if len(stateDir) == 0 {
+		logger.Errorf("label cache: state directory unavailable")
+		return nil
+	}
+
+	path := filepath.Join(stateDir, _labelCacheFile)
+	cache, err := labelcache.Load(path)
+	if err != nil {
+		logger.Errorf("label cache: %v", err)
+		return nil
+	}
+
+	var key string
+	if scope == labelCachePane {
+		key = paneID
+	}
+
+	return &labelMemory{
+		Log:   logger,
+		Cache: cache,
+		Path:  path,
+		Key:   key,
+	}
+}
+
+// Labels returns the labels remembered from a prior run.
+func (m *labelMemory) Labels() map[string]string {
+	if m == nil {
+		return nil
+	}
+	return m.Cache.Get(m.Key)
+}
+
+// Remember records the given labels for the next run.
+func (m *labelMemory) Remember(labels map[string]string) {
+	if m == nil {
+		return
+	}
+
+	m.Cache.Put(m.Key, labels, time.Now())
+	if err := m.Cache.Save(m.Path); err != nil {
+		m.Log.Errorf("label cache: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/log/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelMemory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("off", func(t *testing.T) {
+		t.Parallel()
+
+		m := openLabelMemory(logtest.NewLogger(t), labelCacheOff, t.TempDir(), "%1")
+		assert.Nil(t, m)
+
+		// Operations on a nil memory are no-ops.
+		assert.Nil(t, m.Labels())
+		m.Remember(map[string]string{"foo": "a"})
+	})
+
+	t.Run("no state directory", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, openLabelMemory(logtest.NewLogger(t), labelCachePane, "", "%1"))
+	})
+
+	t.Run("pane", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		log := logtest.NewLogger(t)
+
+		m := openLabelMemory(log, labelCachePane, dir, "%1")
+		require.NotNil(t, m)
+		assert.Empty(t, m.Labels())
+		m.Remember(map[string]string{"foo": "a"})
+
+		m = openLabelMemory(log, labelCachePane, dir, "%1")
+		require.NotNil(t, m)
+		assert.Equal(t, map[string]string{"foo": "a"}, m.Labels())
+
+		m = openLabelMemory(log, labelCachePane, dir, "%2")
+		require.NotNil(t, m)
+		assert.Empty(t, m.Labels(), "other panes must not share labels")
+	})
+
+	t.Run("global", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		log := logtest.NewLogger(t)
+
+		openLabelMemory(log, labelCacheGlobal, dir, "%1").
+			Remember(map[string]string{"foo": "a"})
+
+		m := openLabelMemory(log, labelCacheGlobal, dir, "%2")
+		require.NotNil(t, m)
+		assert.Equal(t, map[string]string{"foo": "a"}, m.Labels())
+	})
+}
@@ -6,6 +6,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ import (
 var _version = "dev"
 
 var _main = mainCmd{
+	Stdin:      os.Stdin,
 	Stdout:     os.Stdout,
 	Stderr:     os.Stderr,
 	Executable: os.Executable,
@@ -30,13 +32,37 @@ var _main = mainCmd{
 }
 
 func main() {
-	if err := run(&_main, os.Args[1:]); err != nil && err != flag.ErrHelp {
-		fmt.Fprintln(_main.Stderr, err)
-		os.Exit(1)
+	err := run(&_main, os.Args[1:])
+	if err == nil || err == flag.ErrHelp {
+		return
 	}
+
+	code := 1
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		code = exitErr.Code
+	}
+	if msg := err.Error(); len(msg) > 0 {
+		fmt.Fprintln(_main.Stderr, msg)
+	}
+	os.Exit(code)
+}
+
+// _subcommands maps the names of subcommands to their implementations.
+var _subcommands = map[string]func(*mainCmd, []string) error{
+	"exec":    runExec,
+	"history": runHistory,
+	"pick":    runPick,
+	"search":  runSearch,
 }
 
 func run(cmd *mainCmd, args []string) (err error) {
+	if len(args) > 0 {
+		if sub, ok := _subcommands[args[0]]; ok {
+			return sub(cmd, args[1:])
+		}
+	}
+
 	var cfg config
 	flag := flag.NewFlagSet(_name, flag.ContinueOnError)
 	flag.SetOutput(cmd.Stderr)
@@ -68,6 +94,7 @@ func run(cmd *mainCmd, args []string) (err error) {
 }
 
 type mainCmd struct {
+	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
 
@@ -76,13 +103,17 @@ type mainCmd struct {
 	Environ    func() []string        // == os.Environ
 	Getpid     func() int
 
-	newTmuxDriver func(string) tmuxShellDriver
+	newTmuxDriver func(*config) tmuxShellDriver
 	runTarget     runTargetFunc
 }
 
 const _name = "tmux-fastcopy"
 
-const _usage = `usage: %v [options]
+const _usage = `usage: %[1]v [options]
+       %[1]v history [options]
+       %[1]v search [options] NAME
+       %[1]v pick [options] [FILE]
+       %[1]v exec [options] -- COMMAND [ARGS ...]
 
 Renders a vimium/vimperator-style overlay on top of the text in a tmux window
 to allow copying important text on the screen.
@@ -101,10 +132,56 @@ The following flags are available:
 		'shift-action' specifies the action with the Shift key pressed.
 		The first '{}' in the argument list is the selected text.
 		If there is no '{}', the selected text is sent over stdin.
-			-action 'tmux load-buffer -'  # default
+			-action 'builtin:buffer'  # default
 			-action pbcopy -shift-action open
-		Uses 'tmux load-buffer' by default for 'action' and no-op for
+		Uses 'builtin:buffer' by default for 'action' and no-op for
 		'shift-action'.
+		The following actions are built into tmux-fastcopy:
+			builtin:buffer
+				copy the text into a new tmux buffer, and with
+				tmux 3.2 or newer, to the clipboard
+			builtin:buffer-named:NAME
+				same as builtin:buffer, but copy into the tmux
+				buffer NAME
+			builtin:append-buffer
+				add the text to the end of the most recent
+				tmux buffer
+			builtin:paste
+				type the text into the pane
+			builtin:osc52
+				copy the text to the clipboard with an OSC 52
+				escape sequence
+	-named-action NAME:COMMAND
+		define an action with the given name that runs COMMAND.
+		Bind it to a key with -bind to use it.
+		This may be provided multiple times.
+			-named-action 'open:xdg-open {}'
+	-bind NAME:KEY
+		request the action with the given name by typing a label
+		with KEY. KEY is either 'alt', to type the label with Alt
+		held down, or a single character to press right before
+		typing the label. The character must not be part of the
+		alphabet or uppercase. NAME may be 'shift' for the
+		shift-action. Ctrl can't be used.
+			-bind open:alt -bind edit:,
+	-regex-action NAME:COMMAND
+	-regex-shift-action NAME:COMMAND
+		command that handles text matched by the regex with the given
+		name in place of 'action' or 'shift-action'. If the selected
+		text was matched by multiple regexes with different actions,
+		'action' or 'shift-action' is used instead.
+		This may be provided multiple times.
+			-regex-action 'url:xdg-open {}'
+			-regex-action 'gitsha:tmux split-window git show {}'
+	-regex-menu NAME:ACTIONS
+		after selecting text matched by the regex with the given name,
+		show a menu to choose one of the named actions in ACTIONS,
+		separated by spaces. Each action is chosen with the key shown
+		next to it, and Enter chooses the first one. ACTIONS may
+		include 'default' for the action and 'shift' for the
+		shift-action. The menu isn't shown with -keys.
+		This may be provided multiple times.
+			-regex-menu 'url:open default' -named-action 'open:xdg-open {}'
 	-regex NAME:PATTERN
 		regular expressions to search for.
 		Name identifies the pattern. Add this option any number of
@@ -122,11 +199,108 @@ The following flags are available:
 	-alphabet STRING
 		characters used to generate labels.
 			-alphabet "asdfghjkl;"  # qwerty home row
+		This may also be the name of one of the following presets:
+		qwerty-home, qwerty-left, dvorak-home, colemak-home,
+		colemak-dh, numeric.
+			-alphabet colemak-dh
 		Uses the English alphabet by default.
+	-label-cache SCOPE
+		remember labels assigned to text between invocations so that
+		the same text gets the same label where possible.
+		SCOPE is one of 'pane', 'global', or 'off'.
+			-label-cache pane
+		Labels are not remembered by default.
+	-label-strategy STRATEGY
+		how labels are generated. STRATEGY is one of the following.
+		  huffman      shortest labels for the most frequent text
+		  alternating  alternate between the first and second
+		               halves of the alphabet for each letter
+		With alternating labels, list the alphabet's left-hand keys
+		first to alternate between hands.
+			-label-strategy alternating
+		Uses huffman by default.
+	-selection-history
+		record the kinds of text selected, and use this to give
+		shorter labels to the kinds of text selected most often.
+		The recorded history is stored locally, and may be inspected
+		or deleted with the history subcommand.
+			%[1]v history
+			%[1]v history -reset
+	-auto-select
+		if there's exactly one unique piece of matched text on the
+		screen, run the action on it right away without showing
+		hints.
+	-auto-select-regex NAME
+		like -auto-select, but only consider text matched by the
+		regex with this name. Other matches are ignored.
+			-auto-select-regex gitsha
+	-select NAME:INDEX
+		select text matched by the regex NAME without showing hints,
+		and run the action on it. INDEX is 'first', 'last', a
+		position from the top of the screen starting at 1, or a
+		negative position from the bottom of the screen.
+			-select gitsha:last  # most recent git SHA
+			-select ipv4:2       # second IPv4 address
+	-keys KEYS
+		type these keys into the overlay instead of reading them from
+		the terminal. The overlay isn't shown, and exits after the
+		keys are typed if nothing was selected by then.
+		Other keys go inside angle brackets: <Tab>, <Enter>, <Esc>,
+		<BS>, <Space>, <Up>, <Down>, <PgUp>, <PgDn>, and <lt> for '<'.
+		Uppercase letters and <S-x> are typed with shift, <C-x> is
+		typed with control, and <M-x> is typed with alt.
+			-keys 'a'               # select the hint labeled 'a'
+			-keys '<Tab>ab<Enter>'  # select 'a' and 'b' together
+	-print
+		print the selected text to stdout instead of running the
+		action.
+			sha=$(%[1]v -print -regex-only gitsha)
+	-print-regex-name
+		with -print, print the names of the regexes that matched the
+		selected text before it, separated by a tab.
+	-regex-only NAME
+		search only for text matched by the regex with this name.
+	-history-lines N
+		also search up to N lines of the scrollback history above the
+		visible area of the pane. Scroll the overlay with PageUp and
+		PageDown, or Ctrl-U and Ctrl-D for half a page.
+			-history-lines 500
+		Searches only the visible area by default.
+	-scope SCOPE
+		how much to search for text. SCOPE is one of the following.
+		  pane     the target pane
+		  window   all visible panes in the window of the target
+		           pane; the overlay covers the whole window
+		  session  all panes in the session of the target pane
+		  server   all panes on the tmux server
+		  buffers  the tmux paste buffers instead of panes
+		With 'session' and 'server', the unique matches are listed
+		along with the panes they were found in instead of being
+		shown in place. See also the search subcommand.
+			%[1]v search gitsha
+		With 'buffers', the overlay shows the contents of all paste
+		buffers, most recent last.
+			-scope window
+		Searches only the target pane by default.
+	-popup
+		show the overlay in a borderless popup placed over the pane
+		instead of swapping it into the pane's place.
+		Requires tmux 3.3 or newer. Falls back to swapping panes if the
+		popup can't be opened.
+	-control-mode
+		talk to tmux over a single control mode connection (tmux -C)
+		instead of running tmux for every command. This may speed up
+		tmux-fastcopy on slow machines.
 	-tmux PATH
 		path to tmux executable.
 			-tmux /usr/bin/tmux
 		Searches $PATH for tmux by default.
+	-socket-name NAME
+	-socket-path PATH
+		name or path of the socket of the tmux server to talk to,
+		like the -L and -S flags of tmux.
+			-socket-name pairing
+		Uses the server that tmux-fastcopy was invoked from by default.
 	-log FILE
 		file to write logs to.
 		Uses stderr by default.
@@ -134,13 +308,15 @@ The following flags are available:
 		log more output.
 	-version
 		display version information.
+
+Exits with status 0 if text was selected, or if nothing was selected without
+-print. Exits with status 1 on errors, and with status 2 if nothing was
+selected with -print. Errors are also shown in the tmux status line.
 `
 
 func (cmd *mainCmd) init() {
 	if cmd.newTmuxDriver == nil {
-		cmd.newTmuxDriver = func(path string) tmuxShellDriver {
-			return &tmux.ShellDriver{Path: path}
-		}
+		cmd.newTmuxDriver = cmd.defaultTmuxDriver
 	}
 
 	if cmd.runTarget == nil {
@@ -160,7 +336,10 @@ func (cmd *mainCmd) Run(cfg *config) (err error) {
 		cmd.Stderr = f
 	}
 
-	tmuxDriver := cmd.newTmuxDriver(cfg.Tmux)
+	tmuxDriver := cmd.newTmuxDriver(cfg)
+	if c, ok := tmuxDriver.(io.Closer); ok {
+		defer multierr.AppendInvoke(&err, multierr.Close(c))
+	}
 
 	// If we're wrapped, wait to send the done signal *after* writing the
 	// panic.
@@ -178,22 +357,64 @@ func (cmd *mainCmd) Run(cfg *config) (err error) {
 	}
 	tmuxDriver.SetLogger(logger.WithName("tmux"))
 
+	version, err := tmux.CheckVersion(tmuxDriver)
+	if err != nil {
+		return err
+	}
+
+	stateDir, err := userStateDir(cmd.Getenv)
+	if err != nil {
+		logger.Debugf("persistent state disabled: %v", err)
+	}
+
+	newAction := (&actionFactory{
+		Log:     logger,
+		Environ: cmd.Environ,
+		Getwd:   os.Getwd,
+		Tmux:    tmuxDriver,
+		Version: version,
+	}).New
+
 	var target interface{ Run(*config) error }
-	if len(parent) > 0 {
+	switch {
+	case len(parent) > 0:
 		target = &app{
+			Stdout:     cmd.Stdout,
+			Log:        logger,
+			Tmux:       tmuxDriver,
+			Version:    version,
+			StateDir:   stateDir,
+			NewScreen:  tcell.NewScreen,
+			NewAction:  newAction,
+			ResultFile: cmd.Getenv(_resultFileEnv),
+		}
+	case cfg.Select.IsSet():
+		// There's no UI so we don't need our own session.
+		target = &directSelect{
+			Stdout:    cmd.Stdout,
 			Log:       logger,
 			Tmux:      tmuxDriver,
-			NewScreen: tcell.NewScreen,
-			NewAction: (&actionFactory{
-				Log:     logger,
-				Environ: cmd.Environ,
-				Getwd:   os.Getwd,
-			}).New,
+			Version:   version,
+			NewAction: newAction,
+		}
+	case cfg.Keys.IsSet():
+		// The UI runs against a simulated terminal,
+		// so we don't need our own session either.
+		target = &app{
+			Stdout:      cmd.Stdout,
+			Log:         logger,
+			Tmux:        tmuxDriver,
+			Version:     version,
+			StateDir:    stateDir,
+			NewAction:   newAction,
+			LoadOptions: true,
 		}
-	} else {
+	default:
 		target = &wrapper{
+			Stdout:     cmd.Stdout,
 			Log:        logger,
 			Tmux:       tmuxDriver,
+			Version:    version,
 			Executable: cmd.Executable,
 			Getenv:     cmd.Getenv,
 			Getpid:     cmd.Getpid,
@@ -203,6 +424,31 @@ func (cmd *mainCmd) Run(cfg *config) (err error) {
 	return cmd.runTarget(target, cfg)
 }
 
+func (cmd *mainCmd) defaultTmuxDriver(cfg *config) tmuxShellDriver {
+	if cfg.ControlMode {
+		// Attach to the session we were invoked from so that commands
+		// without a target run against the same pane as they would
+		// otherwise. If we're talking to a different server, that pane
+		// doesn't belong to it.
+		var session string
+		if len(cfg.SocketName) == 0 && len(cfg.SocketPath) == 0 {
+			session = cmd.Getenv("TMUX_PANE")
+		}
+		return &tmux.ControlDriver{
+			Path:       cfg.Tmux,
+			SocketName: cfg.SocketName,
+			SocketPath: cfg.SocketPath,
+			Session:    session,
+		}
+	}
+
+	return &tmux.ShellDriver{
+		Path:       cfg.Tmux,
+		SocketName: cfg.SocketName,
+		SocketPath: cfg.SocketPath,
+	}
+}
+
 type tmuxShellDriver interface {
 	tmux.Driver
 
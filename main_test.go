@@ -48,6 +48,7 @@ func TestMainParentSignal(t *testing.T) {
 		assert.Empty(t, stderr.String(), "stderr must be empty")
 	}()
 
+	mockTmux.EXPECT().Version().Return(tmux.Version{Major: 3, Minor: 4}, nil)
 	mockTmux.EXPECT().
 		SendSignal(_signalPrefix + "42").
 		Return(nil)
@@ -56,7 +57,7 @@ func TestMainParentSignal(t *testing.T) {
 		Stdout: io.Discard,
 		Stderr: &stderr,
 		Getenv: envtest.MustPairs(_parentPIDEnv, "42").Getenv,
-		newTmuxDriver: func(string) tmuxShellDriver {
+		newTmuxDriver: func(*config) tmuxShellDriver {
 			return fakeTmux{mockTmux}
 		},
 		runTarget: func(interface{ Run(*config) error }, *config) error {
@@ -78,6 +79,7 @@ func TestMainTargetPanicWithLog(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().Version().Return(tmux.Version{Major: 3, Minor: 4}, nil)
 
 	called := false
 	defer func() {
@@ -93,7 +95,7 @@ func TestMainTargetPanicWithLog(t *testing.T) {
 		Stderr: &stderr,
 		Getenv: envtest.Empty.Getenv,
 		Getpid: func() int { return 42 },
-		newTmuxDriver: func(string) tmuxShellDriver {
+		newTmuxDriver: func(*config) tmuxShellDriver {
 			return fakeTmux{mockTmux}
 		},
 		runTarget: runTarget,
@@ -107,3 +109,72 @@ func TestMainTargetPanicWithLog(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, string(body), "panic: great sadness")
 }
+
+func TestMainUnsupportedTmux(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().Version().Return(tmux.Version{Major: 2, Minor: 6}, nil)
+
+	err := (&mainCmd{
+		Stdout: io.Discard,
+		Stderr: io.Discard,
+		Getenv: envtest.Empty.Getenv,
+		newTmuxDriver: func(*config) tmuxShellDriver {
+			return fakeTmux{mockTmux}
+		},
+		runTarget: func(interface{ Run(*config) error }, *config) error {
+			t.Error("target must not run")
+			return nil
+		},
+	}).Run(&config{})
+	assert.ErrorContains(t, err, "tmux 2.6 is not supported")
+}
+
+func TestMainDefaultTmuxDriver(t *testing.T) {
+	t.Parallel()
+
+	cmd := mainCmd{Getenv: envtest.MustPairs("TMUX_PANE", "%42").Getenv}
+
+	t.Run("shell", func(t *testing.T) {
+		t.Parallel()
+
+		got := cmd.defaultTmuxDriver(&config{
+			Tmux:       "/usr/bin/tmux",
+			SocketName: "pairing",
+		})
+		assert.Equal(t, &tmux.ShellDriver{
+			Path:       "/usr/bin/tmux",
+			SocketName: "pairing",
+		}, got)
+	})
+
+	t.Run("control mode", func(t *testing.T) {
+		t.Parallel()
+
+		got := cmd.defaultTmuxDriver(&config{
+			Tmux:        "tmux",
+			ControlMode: true,
+		})
+		assert.Equal(t, &tmux.ControlDriver{
+			Path:    "tmux",
+			Session: "%42",
+		}, got)
+	})
+
+	t.Run("control mode/socket", func(t *testing.T) {
+		t.Parallel()
+
+		// TMUX_PANE is for a different server.
+		got := cmd.defaultTmuxDriver(&config{
+			Tmux:        "tmux",
+			SocketPath:  "/tmp/tmux.sock",
+			ControlMode: true,
+		})
+		assert.Equal(t, &tmux.ControlDriver{
+			Path:       "tmux",
+			SocketPath: "/tmp/tmux.sock",
+		}, got)
+	})
+}
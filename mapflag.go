@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/must"
+)
+
+// mapFlagSpec describes a kind of mapFlag.
+//
+// Implementations are empty structs used only for their methods.
+type mapFlagSpec interface {
+	// FlagName is the name of the command line flag without the leading
+	// "-".
+	FlagName() string
+
+	// FormError is the error message for flag values that are not in the
+	// form NAME:VALUE.
+	FormError() string
+
+	// Check reports an error if the given entry may not be added to the
+	// map.
+	Check(k, v string) error
+}
+
+// mapFlag is a map from names to values that may be specified as a command
+// line flag that's repeated for each entry, or with tmux options that share a
+// prefix. S decides how the flag is named and which entries it accepts.
+type mapFlag[S mapFlagSpec] map[string]string
+
+// Put adds an entry to the map.
+func (m *mapFlag[S]) Put(k, v string) error {
+	var spec S
+	if err := spec.Check(k, v); err != nil {
+		return err
+	}
+
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+	(*m)[k] = v
+	return nil
+}
+
+// Flags returns the command line arguments that rebuild this map.
+func (m mapFlag[S]) Flags() (args []string) {
+	var spec S
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		args = append(args, "-"+spec.FlagName(), name+":"+m[name])
+	}
+	return args
+}
+
+func (m mapFlag[S]) String() string {
+	return fmt.Sprint(m.Flags())
+}
+
+// Set adds an entry to the map from a NAME:VALUE flag value.
+func (m *mapFlag[S]) Set(v string) error {
+	idx := strings.IndexByte(v, ':')
+	if idx < 0 {
+		var spec S
+		return errors.New(spec.FormError())
+	}
+
+	return m.Put(v[:idx], v[idx+1:])
+}
+
+// FillFrom adds entries from the given map that aren't already present in
+// this one.
+func (m *mapFlag[S]) FillFrom(o mapFlag[S]) {
+	for k, v := range o {
+		if _, ok := (*m)[k]; !ok {
+			err := m.Put(k, v)
+			must.NotErrorf(err, "unexpected invalid key %q", k)
+		}
+	}
+}
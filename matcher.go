@@ -4,12 +4,29 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/ui"
 )
 
 type matcher []*regexpMatcher
 
+// newMatcher compiles a matcher for the given regexes.
+func newMatcher(rs regexes) (matcher, error) {
+	m := make(matcher, 0, len(rs))
+	for name, reg := range rs {
+		rm, err := compileRegexpMatcher(name, reg)
+		if err != nil {
+			return nil, fmt.Errorf("compile regex %q: %v", name, reg)
+		}
+		if rm != nil {
+			m = append(m, rm)
+		}
+	}
+	return m, nil
+}
+
 func (rms matcher) Match(s string) []fastcopy.Match {
 	var ms []match
 	for _, m := range rms {
@@ -27,6 +44,58 @@ func (rms matcher) Match(s string) []fastcopy.Match {
 	return rs
 }
 
+// MatchWrapped finds matches in text captured from a pane of the given width
+// with lines that wrapped at the edge of the pane joined together, so that
+// matches may run across rows.
+//
+// It returns the text wrapped again at the same width, the way it was on the
+// screen, and the matches in that text. Matches that run across rows are
+// split at the end of each row.
+func (rms matcher) MatchWrapped(text string, width int) (string, []fastcopy.Match) {
+	matches := rms.Match(text)
+	wraps := ui.WrapOffsets(text, width)
+	if len(wraps) == 0 {
+		return text, matches
+	}
+
+	var (
+		sb   strings.Builder
+		last int
+	)
+	for _, off := range wraps {
+		sb.WriteString(text[last:off])
+		sb.WriteString("\n")
+		last = off
+	}
+	sb.WriteString(text[last:])
+
+	for i, m := range matches {
+		matches[i] = wrapMatch(m, wraps)
+	}
+	return sb.String(), matches
+}
+
+// wrapMatch maps a match in some text to the same text with a newline added
+// before each of the given offsets, splitting it at those newlines.
+func wrapMatch(m fastcopy.Match, wraps []int) fastcopy.Match {
+	var ranges []fastcopy.Range
+
+	// Number of newlines added before start.
+	n := sort.SearchInts(wraps, m.Range.Start+1)
+	start := m.Range.Start
+	for ; n < len(wraps) && wraps[n] < m.Range.End; n++ {
+		ranges = append(ranges, fastcopy.Range{Start: start + n, End: wraps[n] + n})
+		start = wraps[n]
+	}
+	ranges = append(ranges, fastcopy.Range{Start: start + n, End: m.Range.End + n})
+
+	m.Range, m.Wrapped = ranges[0], ranges[1:]
+	if len(m.Wrapped) == 0 {
+		m.Wrapped = nil
+	}
+	return m
+}
+
 func (rms matcher) removeOverlaps(ms []match) []match {
 	if len(ms) < 2 {
 		return ms
@@ -35,17 +104,21 @@ func (rms matcher) removeOverlaps(ms []match) []match {
 	// Sort in ascending order by:
 	// - Starts earliest
 	// - Runs longest
+	// - Name of the matcher
+	//
+	// The last one makes sure that the same regex wins every time if
+	// several of them match the same text.
 	sort.Slice(ms, func(i, j int) bool {
 		l, r := ms[i].Full, ms[j].Full
 
-		if l.Start < r.Start {
-			return true
+		if l.Start != r.Start {
+			return l.Start < r.Start
 		}
-		if l.Start > r.Start {
-			return false
+		if l.Len() != r.Len() {
+			return l.Len() > r.Len()
 		}
 
-		return l.Len() > r.Len()
+		return ms[i].Matcher < ms[j].Matcher
 	})
 
 	out := ms[:1]
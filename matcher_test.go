@@ -3,6 +3,8 @@ package main
 import (
 	"testing"
 
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -70,3 +72,51 @@ func TestRegexpMatcher(t *testing.T) {
 		})
 	}
 }
+
+func TestMatcher_overlap(t *testing.T) {
+	t.Parallel()
+
+	m, err := newMatcher(regexes{
+		"int":  `\d+`,
+		"hex":  `[a-f\d]{4,}`,
+		"word": `\w+`,
+	})
+	require.NoError(t, err)
+
+	// Regexes are kept in a map, so try a few times to make sure that
+	// the order they're matched in doesn't matter.
+	for i := 0; i < 10; i++ {
+		var got []string
+		for _, m := range m.Match("deadbeef 1234 foo 12") {
+			got = append(got, m.Matcher)
+		}
+		assert.Equal(t, []string{"hex", "hex", "word", "int"}, got)
+	}
+}
+
+func TestMatcher_MatchWrapped(t *testing.T) {
+	t.Parallel()
+
+	m, err := newMatcher(regexes{"url": `https://\S+`})
+	require.NoError(t, err)
+
+	// "https://example.com/a/b/c" wraps twice at width 10.
+	text, matches := m.MatchWrapped("see https://example.com/a/b/c ok\nhttps://x\n", 10)
+	assert.Equal(t, "see https:\n//example.\ncom/a/b/c \nok\nhttps://x\n", text)
+	assert.Equal(t, []fastcopy.Match{
+		{
+			Matcher: "url",
+			Range:   fastcopy.Range{Start: 4, End: 10},
+			Wrapped: []fastcopy.Range{
+				{Start: 11, End: 21},
+				{Start: 22, End: 31},
+			},
+		},
+		{Matcher: "url", Range: fastcopy.Range{Start: 36, End: 45}},
+	}, matches)
+
+	for _, match := range matches {
+		assert.NotContains(t, match.TextIn(text), "\n")
+	}
+	assert.Equal(t, "https://example.com/a/b/c", matches[0].TextIn(text))
+}
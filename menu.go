@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/ui"
+)
+
+// regexMenus is a map from regex name to the space-separated names of the
+// actions to offer in a menu after text matched by that regex is selected.
+type regexMenus = mapFlag[regexMenuSpec]
+
+type regexMenuSpec struct{}
+
+func (regexMenuSpec) FlagName() string { return "regex-menu" }
+
+func (regexMenuSpec) FormError() string {
+	return "regex menus must be in the form NAME:ACTIONS"
+}
+
+func (regexMenuSpec) Check(k, _ string) error {
+	if len(k) == 0 {
+		return errors.New("regex menu must have a regex name")
+	}
+	return nil
+}
+
+// menuFor reports the names of the actions to offer in a menu after the
+// given selection: the actions in the menus of all regexes that matched it,
+// in order, without duplicates.
+func (c *config) menuFor(selection fastcopy.Selection) []string {
+	var (
+		actions []string
+		seen    = make(map[string]struct{})
+	)
+	for _, regex := range selection.Matchers {
+		for _, name := range strings.Fields(c.RegexMenus[regex]) {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			actions = append(actions, name)
+		}
+	}
+	return actions
+}
+
+// checkMenus verifies that all actions listed in menus exist.
+//
+// Besides named actions, menus may offer the default action and the shift
+// action.
+func (c *config) checkMenus() error {
+	regexes := make([]string, 0, len(c.RegexMenus))
+	for regex := range c.RegexMenus {
+		regexes = append(regexes, regex)
+	}
+	sort.Strings(regexes)
+
+	for _, regex := range regexes {
+		for _, name := range strings.Fields(c.RegexMenus[regex]) {
+			switch name {
+			case _defaultActionName, fastcopy.ShiftAction:
+				continue
+			}
+			if len(c.NamedActions[name]) == 0 {
+				return fmt.Errorf("menu %v: action %q is not defined", regex, name)
+			}
+		}
+	}
+	return nil
+}
+
+// menuItems builds menu items for the given actions.
+//
+// Each action is chosen with the first letter of its name that isn't already
+// used by an earlier action, or with a digit if there are none left.
+// Actions that run out of keys are left out.
+func menuItems(actions []string) []ui.MenuItem {
+	used := make(map[rune]struct{})
+	items := make([]ui.MenuItem, 0, len(actions))
+	for _, name := range actions {
+		key, ok := menuKey(name, used)
+		if !ok {
+			continue
+		}
+		used[key] = struct{}{}
+		items = append(items, ui.MenuItem{Key: string(key), Label: name})
+	}
+	return items
+}
+
+func menuKey(name string, used map[rune]struct{}) (rune, bool) {
+	for _, r := range strings.ToLower(name) {
+		if _, ok := used[r]; !ok && unicode.IsLetter(r) {
+			return r, true
+		}
+	}
+	for r := '1'; r <= '9'; r++ {
+		if _, ok := used[r]; !ok {
+			return r, true
+		}
+	}
+	return 0, false
+}
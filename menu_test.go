@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/ui"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigMenuFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := config{
+		RegexMenus: regexMenus{
+			"url":    "open copy",
+			"path":   "edit  copy",
+			"gitsha": "",
+		},
+	}
+
+	tests := []struct {
+		desc string
+		give []string // matchers
+		want []string
+	}{
+		{desc: "no menu", give: []string{"int"}},
+		{desc: "empty menu", give: []string{"gitsha"}},
+		{desc: "single", give: []string{"url"}, want: []string{"open", "copy"}},
+		{
+			desc: "merged",
+			give: []string{"path", "url"},
+			want: []string{"edit", "copy", "open"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := cfg.menuFor(fastcopy.Selection{Text: "x", Matchers: tt.give})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConfigCheckMenus(t *testing.T) {
+	t.Parallel()
+
+	cfg := config{
+		NamedActions: namedActions{"open": "xdg-open {}"},
+		RegexMenus:   regexMenus{"url": "open shift default"},
+	}
+	assert.NoError(t, cfg.checkMenus())
+
+	cfg.RegexMenus["path"] = "open edit"
+	assert.ErrorContains(t, cfg.checkMenus(), `menu path: action "edit" is not defined`)
+}
+
+func TestMenuItems(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []ui.MenuItem{
+		{Key: "c", Label: "copy"},
+		{Key: "o", Label: "open"},
+		{Key: "p", Label: "paste"},
+		{Key: "e", Label: "edit"},
+		{Key: "s", Label: "search"},
+		{Key: "l", Label: "clip"},
+		{Key: "1", Label: "cc"},
+	}, menuItems([]string{"copy", "open", "paste", "edit", "search", "clip", "cc"}))
+}
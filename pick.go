@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+	tcell "github.com/gdamore/tcell/v3"
+	"github.com/rivo/uniseg"
+	"go.uber.org/multierr"
+)
+
+// Size of the simulated terminal used to pick with -keys.
+const (
+	_pickKeysWidth  = 80
+	_pickKeysHeight = 24
+)
+
+const _pickUsage = `usage: %[1]v pick [options] [FILE]
+
+Shows the overlay on the terminal for text read from FILE, or from stdin if
+FILE is '-' or unspecified, and prints the selected text to stdout.
+This does not need tmux.
+
+	git log --oneline -50 | %[1]v pick -regex-only gitsha
+
+The following flags are available. They work the same as they do for
+%[1]v; see '%[1]v -help' for details.
+
+	-regex NAME:PATTERN
+	-regex-only NAME
+	-alphabet STRING
+	-label-strategy STRATEGY
+	-auto-select
+	-auto-select-regex NAME
+	-select NAME:INDEX
+	-keys KEYS
+	-print-regex-name
+
+Exits with status 0 if text was selected, 1 on errors, and 2 if nothing was
+selected.
+`
+
+// runPick implements the pick subcommand.
+func runPick(cmd *mainCmd, args []string) (err error) {
+	var cfg config
+	flag := flag.NewFlagSet(_name+" pick", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.Output(), _pickUsage, _name)
+	}
+	cfg.registerPickFlags(flag)
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+
+	in := cmd.Stdin
+	switch args := flag.Args(); {
+	case len(args) > 1:
+		return fmt.Errorf("unexpected arguments %q", args[1:])
+	case len(args) == 1 && args[0] != "-":
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer multierr.AppendInvoke(&err, multierr.Close(f))
+		in = f
+	}
+
+	text, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("read text: %v", err)
+	}
+
+	sel, err := (&picker{
+		Log:       log.New(cmd.Stderr),
+		NewScreen: tcell.NewScreen,
+	}).Pick(&cfg, string(text))
+	if err != nil {
+		return err
+	}
+	if len(sel.Text) == 0 {
+		return _errCancelled
+	}
+	return printSelection(cmd.Stdout, &cfg, sel)
+}
+
+// registerPickFlags registers the flags that are used by picker.
+// They share their names with the flags registered by RegisterFlags.
+func (c *config) registerPickFlags(flag *flag.FlagSet) {
+	flag.Var(&c.Regexes, "regex", "")
+	flag.StringVar(&c.RegexOnly, "regex-only", "", "")
+	flag.Var(&c.Alphabet, "alphabet", "")
+	flag.Var(&c.LabelStrategy, "label-strategy", "")
+	flag.BoolVar(&c.AutoSelect, "auto-select", false, "")
+	flag.StringVar(&c.AutoSelectRegex, "auto-select-regex", "", "")
+	flag.Var(&c.Select, "select", "")
+	flag.Var(&c.Keys, "keys", "")
+	flag.BoolVar(&c.PrintRegexName, "print-regex-name", false, "")
+}
+
+// picker shows the fastcopy UI for text that doesn't come from a tmux pane,
+// and reports the selection. It uses the terminal directly, so it doesn't
+// need tmux.
+type picker struct {
+	Log       *log.Logger
+	NewScreen func() (tcell.Screen, error) // == tcell.NewScreen
+}
+
+// Pick shows the UI for the given text, and returns the selection made by the
+// user. The selection is empty if the user didn't select anything.
+//
+// Like the UI for tmux panes, this honors -select, -auto-select, and -keys.
+func (p *picker) Pick(cfg *config, text string) (fastcopy.Selection, error) {
+	cfg.FillFrom(&config{
+		Alphabet: _defaultAlphabet,
+		Regexes:  _defaultRegexes,
+	})
+	for _, w := range cfg.Alphabet.Warnings() {
+		p.Log.Infof("%v", w)
+	}
+
+	matcher, err := cfg.newMatcher()
+	if err != nil {
+		return fastcopy.Selection{}, err
+	}
+
+	altAction, leaderActions, err := cfg.actionKeys()
+	if err != nil {
+		return fastcopy.Selection{}, err
+	}
+	if err := cfg.checkMenus(); err != nil {
+		return fastcopy.Selection{}, err
+	}
+
+	text = cleanText(text)
+	matches := matcher.Match(text)
+
+	if cfg.Select.IsSet() {
+		if len(cfg.Regexes[cfg.Select.Regex]) == 0 {
+			return fastcopy.Selection{}, fmt.Errorf("select: regex %q is not defined", cfg.Select.Regex)
+		}
+		sel, ok := cfg.Select.Pick(text, matches)
+		if !ok {
+			return fastcopy.Selection{}, fmt.Errorf("select %v: no such match", cfg.Select.String())
+		}
+		return sel, nil
+	}
+
+	if cfg.AutoSelect || len(cfg.AutoSelectRegex) > 0 {
+		if sel, ok := autoSelect(text, matches, cfg.AutoSelectRegex); ok {
+			p.Log.Debugf("auto-selected %q", sel.Text)
+			return sel, nil
+		}
+	}
+
+	var screen tcell.Screen
+	if cfg.Keys.IsSet() {
+		screen, err = newHeadlessScreen(_pickKeysWidth, _pickKeysHeight)
+	} else {
+		screen, err = p.NewScreen()
+	}
+	if err != nil {
+		return fastcopy.Selection{}, err
+	}
+
+	if err := screen.Init(); err != nil {
+		return fastcopy.Selection{}, err
+	}
+	defer screen.Fini()
+
+	ctrl := ctrl{
+		Screen:        screen,
+		Log:           p.Log,
+		Text:          text,
+		Alphabet:      []rune(cfg.Alphabet),
+		Matches:       matches,
+		LabelStrategy: cfg.LabelStrategy.Strategy(),
+		AltAction:     altAction,
+		LeaderActions: leaderActions,
+	}
+	// Scripted keys end with Escape,
+	// which would close the menu without choosing anything.
+	if !cfg.Print && !cfg.Keys.IsSet() {
+		ctrl.Menu = cfg.menuFor
+	}
+	ctrl.Init()
+
+	if cfg.Keys.IsSet() {
+		stop := make(chan struct{})
+		defer close(stop)
+		go postKeys(screen, cfg.Keys.Events(), stop)
+	}
+
+	return ctrl.Wait()
+}
+
+// cleanText prepares text from outside tmux to be drawn like text captured
+// from a pane: tabs are expanded to spaces, and carriage returns are dropped.
+func cleanText(text string) string {
+	if !strings.ContainsAny(text, "\t\r") {
+		return text
+	}
+
+	var (
+		sb  strings.Builder
+		col int // column in the current line
+	)
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		switch s := g.Str(); s {
+		case "\r":
+			// Drop it.
+		case "\r\n", "\n":
+			sb.WriteString("\n")
+			col = 0
+		case "\t":
+			n := 8 - col%8
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+		default:
+			sb.WriteString(s)
+			col += g.Width()
+		}
+	}
+	return sb.String()
+}
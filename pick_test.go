@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/envtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickCommand(t *testing.T) {
+	t.Parallel()
+
+	const text = "commit 4f3c2a1 at 10.0.0.1\nmerge a09be41f\n"
+
+	file := filepath.Join(t.TempDir(), "input.txt")
+	require.NoError(t, os.WriteFile(file, []byte(text), 0o644))
+
+	tests := []struct {
+		desc  string
+		args  []string
+		stdin string
+
+		want     string
+		wantCode int // exit code if non-zero
+		wantErr  string
+	}{
+		{
+			desc:  "select",
+			args:  []string{"-select", "gitsha:last"},
+			stdin: text,
+			want:  "a09be41f\n",
+		},
+		{
+			desc:  "select/regex name",
+			args:  []string{"-select", "ipv4:1", "-print-regex-name"},
+			stdin: text,
+			want:  "ipv4\t10.0.0.1\n",
+		},
+		{
+			desc:  "select/missing",
+			args:  []string{"-select", "uuid:1"},
+			stdin: text,
+			// uuid is defined by default.
+			wantErr: "select uuid:1: no such match",
+		},
+		{
+			desc:  "auto select",
+			args:  []string{"-auto-select-regex", "ipv4"},
+			stdin: text,
+			want:  "10.0.0.1\n",
+		},
+		{
+			desc:  "keys",
+			args:  []string{"-regex-only", "gitsha", "-alphabet", "ab", "-keys", "b"},
+			stdin: text,
+			want:  "a09be41f\n",
+		},
+		{
+			desc:  "custom regex",
+			args:  []string{"-regex", "word:merge", "-regex-only", "word", "-auto-select"},
+			stdin: text,
+			want:  "merge\n",
+		},
+		{
+			desc:     "cancelled",
+			args:     []string{"-keys", "<Esc>"},
+			stdin:    text,
+			wantCode: 2,
+		},
+		{
+			desc: "file",
+			args: []string{"-select", "gitsha:first", file},
+			want: "4f3c2a1\n",
+		},
+		{
+			desc:  "stdin dash",
+			args:  []string{"-select", "gitsha:first", "-"},
+			stdin: text,
+			want:  "4f3c2a1\n",
+		},
+		{
+			desc:    "missing file",
+			args:    []string{filepath.Join(t.TempDir(), "missing.txt")},
+			wantErr: "no such file",
+		},
+		{
+			desc:    "too many files",
+			args:    []string{file, file},
+			wantErr: "unexpected arguments",
+		},
+		{
+			desc:    "unknown regex-only",
+			args:    []string{"-regex-only", "foo"},
+			stdin:   text,
+			wantErr: `regex "foo" is not defined`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			err := run(&mainCmd{
+				Stdin:  strings.NewReader(tt.stdin),
+				Stdout: &stdout,
+				Stderr: &stderr,
+				Getenv: envtest.Empty.Getenv,
+			}, append([]string{"pick"}, tt.args...))
+
+			switch {
+			case len(tt.wantErr) > 0:
+				assert.ErrorContains(t, err, tt.wantErr)
+			case tt.wantCode != 0:
+				var exitErr *exitError
+				require.True(t, errors.As(err, &exitErr), "want exitError, got %v", err)
+				assert.Equal(t, tt.wantCode, exitErr.Code)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+		want string
+	}{
+		{desc: "plain", give: "foo\nbar\n", want: "foo\nbar\n"},
+		{desc: "crlf", give: "foo\r\nbar\r\n", want: "foo\nbar\n"},
+		{desc: "tabs", give: "a\tb\n\tc", want: "a       b\n        c"},
+		{desc: "wide tabs", give: "文\tb", want: "文      b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, cleanText(tt.give))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/statefile"
+)
+
+// _resultFileEnv is the environment variable through which the wrapper tells
+// the wrapped tmux-fastcopy where to write its result.
+const _resultFileEnv = "TMUX_FASTCOPY_RESULT_FILE"
+
+// resultStatus specifies how a run of tmux-fastcopy ended.
+type resultStatus string
+
+const (
+	// The user selected some text.
+	statusSelected resultStatus = "selected"
+
+	// The user exited without selecting anything.
+	statusCancelled resultStatus = "cancelled"
+
+	// Something went wrong.
+	statusError resultStatus = "error"
+)
+
+// result is the outcome of a wrapped tmux-fastcopy, reported back to the
+// wrapper through a file.
+type result struct {
+	Status resultStatus `json:"status"`
+
+	// Error message if Status is statusError.
+	Error string `json:"error,omitempty"`
+
+	// Text that was selected, if any.
+	Text string `json:"text,omitempty"`
+
+	// Names of the regexes that matched the selected text.
+	Regexes []string `json:"regexes,omitempty"`
+}
+
+// newResult builds a result from the selection made by the user, and the
+// error that ended the run, if any.
+func newResult(sel fastcopy.Selection, err error) result {
+	r := result{Text: sel.Text, Regexes: sel.Matchers}
+	switch {
+	case err != nil:
+		r.Status = statusError
+		r.Error = err.Error()
+	case len(sel.Text) == 0:
+		r.Status = statusCancelled
+	default:
+		r.Status = statusSelected
+	}
+	return r
+}
+
+// Selection returns the selection reported in this result.
+func (r *result) Selection() fastcopy.Selection {
+	return fastcopy.Selection{Text: r.Text, Matchers: r.Regexes}
+}
+
+// exitError is an error that exits tmux-fastcopy with a specific exit code.
+// Nothing is printed if the message is empty.
+type exitError struct {
+	Code int
+	Msg  string
+}
+
+func (e *exitError) Error() string {
+	return e.Msg
+}
+
+// _errCancelled is returned when the user didn't select anything, and the
+// caller asked to be told about it with -print.
+var _errCancelled = &exitError{Code: 2}
+
+func writeResult(path string, r result) error {
+	if err := statefile.Write(path, r); err != nil {
+		return fmt.Errorf("write result: %v", err)
+	}
+	return nil
+}
+
+// readResult reads the result written to the given path. It reports false if
+// the wrapped tmux-fastcopy didn't write a result.
+func readResult(path string) (r result, ok bool, err error) {
+	ok, err = statefile.Read(path, &r)
+	if err != nil {
+		return r, false, fmt.Errorf("read result: %v", err)
+	}
+	return r, ok, nil
+}
+
+// printSelection prints the selection for -print. It prints nothing if
+// nothing was selected.
+func printSelection(w io.Writer, cfg *config, sel fastcopy.Selection) error {
+	if len(sel.Text) == 0 {
+		return nil
+	}
+
+	var err error
+	if cfg.PrintRegexName {
+		_, err = fmt.Fprintf(w, "%v\t%v\n", strings.Join(sel.Matchers, " "), sel.Text)
+	} else {
+		_, err = fmt.Fprintln(w, sel.Text)
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "result")
+
+	_, ok, err := readResult(path)
+	require.NoError(t, err)
+	assert.False(t, ok, "result must not exist yet")
+
+	want := result{Text: "foo", Regexes: []string{"x", "y"}}
+	require.NoError(t, writeResult(path, want))
+
+	got, ok, err := readResult(path)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, want, got)
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "foo",
+		Matchers: []string{"x", "y"},
+	}, got.Selection())
+}
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+
+	sel := fastcopy.Selection{Text: "foo", Matchers: []string{"x"}}
+
+	assert.Equal(t,
+		result{Status: statusSelected, Text: "foo", Regexes: []string{"x"}},
+		newResult(sel, nil))
+	assert.Equal(t,
+		result{Status: statusCancelled},
+		newResult(fastcopy.Selection{}, nil))
+	assert.Equal(t,
+		result{Status: statusError, Error: "great sadness", Text: "foo", Regexes: []string{"x"}},
+		newResult(sel, errors.New("great sadness")))
+}
+
+func TestPrintSelection(t *testing.T) {
+	t.Parallel()
+
+	sel := fastcopy.Selection{Text: "foo bar", Matchers: []string{"x", "y"}}
+
+	tests := []struct {
+		desc string
+		cfg  config
+		sel  fastcopy.Selection
+		want string
+	}{
+		{desc: "text", sel: sel, want: "foo bar\n"},
+		{
+			desc: "regex name",
+			cfg:  config{PrintRegexName: true},
+			sel:  sel,
+			want: "x y\tfoo bar\n",
+		},
+		{desc: "nothing selected"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buff bytes.Buffer
+			require.NoError(t, printSelection(&buff, &tt.cfg, tt.sel))
+			assert.Equal(t, tt.want, buff.String())
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/rivo/uniseg"
+)
+
+// Number of lines of scrollback history searched by the search subcommand
+// in each pane, unless specified otherwise.
+const _searchHistoryLines = 2000
+
+const _searchUsage = `usage: %[1]v search [options] NAME
+
+Searches the scrollback history of all panes in the current session for text
+matched by the regex NAME, and lists the unique matches along with the panes
+they were found in. Select text from the list with its label like in the
+overlay.
+
+	%[1]v search gitsha
+
+The following flags are available:
+
+	-server
+		search all panes on the tmux server instead of only those in
+		the current session.
+	-history-lines N
+		lines of scrollback history to search in each pane.
+		Searches %[2]d lines by default.
+
+All other flags accepted by %[1]v are also accepted here.
+`
+
+// runSearch implements the search subcommand.
+func runSearch(cmd *mainCmd, args []string) error {
+	var cfg config
+	flag := flag.NewFlagSet(_name+" search", flag.ContinueOnError)
+	flag.SetOutput(cmd.Stderr)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.Output(), _searchUsage, _name, _searchHistoryLines)
+	}
+	cfg.RegisterFlags(flag)
+	cfg.HistoryLines = _searchHistoryLines // unless overridden by a flag
+	server := flag.Bool("server", false, "")
+	if err := flag.Parse(args); err != nil {
+		return err
+	}
+
+	args = flag.Args()
+	if len(args) == 0 {
+		return errors.New("please specify the name of a regex to search for")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("unexpected arguments %q", args[1:])
+	}
+
+	cfg.RegexOnly = args[0]
+	cfg.Scope = captureScopeSession
+	if *server {
+		cfg.Scope = captureScopeServer
+	}
+
+	return cmd.Run(&cfg)
+}
+
+// listEntry is a unique piece of text in a list of matches.
+type listEntry struct {
+	Text     string
+	Matchers []string         // names of regexes that matched the text
+	Panes    []*tmux.PaneInfo // panes the text was found in
+}
+
+// captureList captures the text of all panes in the scope requested by cfg,
+// including the requested lines of scrollback history, and lists the unique
+// text matched in them, one per line, along with the panes it was found in.
+//
+// Text is listed in the order it was found. Matches in the list cover the
+// listed text.
+func captureList(
+	driver tmux.Driver,
+	version tmux.Version,
+	cfg *config,
+	m matcher,
+	targetPane *tmux.PaneInfo,
+) (*capture, error) {
+	var (
+		panes []*tmux.PaneInfo
+		err   error
+	)
+	if cfg.Scope == captureScopeServer {
+		panes, err = tmux.InspectServer(driver)
+	} else {
+		panes, err = tmux.InspectSession(driver, targetPane.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list panes: %v", err)
+	}
+
+	var entries []*listEntry
+	byText := make(map[string]*listEntry)
+	for _, pane := range panes {
+		text, err := capturePane(driver, version, pane, cfg.HistoryLines)
+		if err != nil {
+			return nil, fmt.Errorf("capture pane %q: %v", pane.ID, err)
+		}
+
+		for _, match := range m.Match(text) {
+			matched := text[match.Range.Start:match.Range.End]
+			if strings.Contains(matched, "\n") {
+				// Each entry must fit on a line.
+				continue
+			}
+
+			e, ok := byText[matched]
+			if !ok {
+				e = &listEntry{Text: matched}
+				byText[matched] = e
+				entries = append(entries, e)
+			}
+			e.addMatcher(match.Matcher)
+			e.addPane(pane)
+		}
+	}
+
+	return listCapture(entries, targetPane), nil
+}
+
+func (e *listEntry) addMatcher(name string) {
+	for _, m := range e.Matchers {
+		if m == name {
+			return
+		}
+	}
+	e.Matchers = append(e.Matchers, name)
+}
+
+func (e *listEntry) addPane(pane *tmux.PaneInfo) {
+	for _, p := range e.Panes {
+		if p.ID == pane.ID {
+			return
+		}
+	}
+	e.Panes = append(e.Panes, pane)
+}
+
+// listCapture lays out the given entries as a list to show in place of the
+// target pane.
+//
+//	4f3c2a1   main:1.0
+//	a09be41f  main:1.0, main:2.1
+func listCapture(entries []*listEntry, targetPane *tmux.PaneInfo) *capture {
+	var width int
+	for _, e := range entries {
+		width = max(width, uniseg.StringWidth(e.Text))
+	}
+
+	c := capture{
+		Width:  targetPane.Width,
+		Height: targetPane.Height,
+		Pane:   targetPane,
+	}
+
+	var sb strings.Builder
+	for _, e := range entries {
+		start := sb.Len()
+		sb.WriteString(e.Text)
+		end := sb.Len()
+
+		sb.WriteString(strings.Repeat(" ", width-uniseg.StringWidth(e.Text)+2))
+		pane := e.Panes[0]
+		for i, p := range e.Panes {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(p.Location())
+			if p.ID == targetPane.ID {
+				// Prefer the target pane like PaneOf.
+				pane = p
+			}
+		}
+		sb.WriteString("\n")
+
+		for _, name := range e.Matchers {
+			c.Matches = append(c.Matches, fastcopy.Match{
+				Matcher: name,
+				Range:   fastcopy.Range{Start: start, End: end},
+			})
+			c.matchPanes = append(c.matchPanes, pane)
+		}
+	}
+	c.Text = sb.String()
+	return &c
+}
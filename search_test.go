@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/envtest"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSearchCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    []string
+		want    config
+		wantErr string
+	}{
+		{
+			desc: "session",
+			give: []string{"gitsha"},
+			want: config{
+				RegexOnly:    "gitsha",
+				Scope:        captureScopeSession,
+				HistoryLines: _searchHistoryLines,
+			},
+		},
+		{
+			desc: "server",
+			give: []string{"-server", "-history-lines", "10", "-popup", "uuid"},
+			want: config{
+				RegexOnly:    "uuid",
+				Scope:        captureScopeServer,
+				HistoryLines: 10,
+				Popup:        true,
+			},
+		},
+		{
+			desc:    "no name",
+			give:    []string{"-server"},
+			wantErr: "please specify the name of a regex",
+		},
+		{
+			desc:    "too many names",
+			give:    []string{"gitsha", "uuid"},
+			wantErr: `unexpected arguments ["uuid"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			mockTmux := tmuxtest.NewMockDriver(mockCtrl)
+			mockTmux.EXPECT().Version().Return(tmux.Version{Major: 3, Minor: 4}, nil).AnyTimes()
+
+			var got *config
+			var stderr bytes.Buffer
+			err := run(&mainCmd{
+				Stdout: io.Discard,
+				Stderr: &stderr,
+				Getenv: envtest.Empty.Getenv,
+				newTmuxDriver: func(*config) tmuxShellDriver {
+					return fakeTmux{mockTmux}
+				},
+				runTarget: func(_ interface{ Run(*config) error }, cfg *config) error {
+					got = cfg
+					return nil
+				},
+			}, append([]string{"search"}, tt.give...))
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			tt.want.Tmux = "tmux"
+			assert.Equal(t, &tt.want, got)
+		})
+	}
+}
+
+func TestCaptureList(t *testing.T) {
+	t.Parallel()
+
+	targetPane := &tmux.PaneInfo{
+		ID:          "%2",
+		Width:       30,
+		Height:      10,
+		SessionName: "main",
+		WindowIndex: 1,
+	}
+	panes := []*tmux.PaneInfo{
+		{ID: "%1", Height: 10, SessionName: "main"},
+		targetPane,
+		{ID: "%3", Height: 10, SessionName: "other", PaneIndex: 2},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		ListPanes(gomock.Any()).
+		DoAndReturn(func(req tmux.ListPanesRequest) ([]byte, error) {
+			assert.True(t, req.All)
+			var out bytes.Buffer
+			for _, p := range panes {
+				out.WriteString(p.ID + "\t@1\t" + "80\t10\tnormal-mode\t0\t0\t/\t0\t0\t" +
+					p.SessionName + "\t" + strconv.Itoa(p.WindowIndex) + "\t" + strconv.Itoa(p.PaneIndex) + "\n")
+			}
+			return out.Bytes(), nil
+		})
+	for _, tt := range []struct{ pane, text string }{
+		{"%1", "deadbeef 1234\n"},
+		{"%2", "cafe 1234\n"},
+		{"%3", "no numbers\n"},
+	} {
+		tmuxDriver.EXPECT().
+			CapturePane(tmux.CapturePaneRequest{
+				Pane:      tt.pane,
+				StartLine: -100,
+				JoinLines: true,
+			}).
+			Return([]byte(tt.text), nil)
+	}
+
+	m, err := newMatcher(regexes{
+		"int": `\d+`,
+		"hex": `[a-f\d]{4,}`,
+	})
+	require.NoError(t, err)
+
+	cfg := config{Scope: captureScopeServer, HistoryLines: 100}
+	got, err := captureText(tmuxDriver, tmux.Version{Major: 3, Minor: 3}, &cfg, m, targetPane)
+	require.NoError(t, err)
+
+	assert.Equal(t, "deadbeef  main:0.0\n"+
+		"1234      main:0.0, main:1.0\n"+
+		"cafe      main:1.0\n", got.Text)
+	assert.Equal(t, 30, got.Width)
+	assert.Equal(t, 10, got.Height)
+	assert.False(t, got.Window)
+
+	var matched []string
+	for _, m := range got.Matches {
+		matched = append(matched, m.Matcher+":"+got.Text[m.Range.Start:m.Range.End])
+	}
+	assert.ElementsMatch(t, []string{
+		"hex:deadbeef",
+		"hex:1234", // both match; hex wins by name
+		"hex:cafe",
+	}, matched)
+
+	assert.Equal(t, "%1", got.PaneOf("deadbeef").ID)
+	assert.Equal(t, "%2", got.PaneOf("1234").ID, "target pane is preferred")
+	assert.Equal(t, "%2", got.PaneOf("cafe").ID)
+}
+
+func TestListCapture_empty(t *testing.T) {
+	t.Parallel()
+
+	pane := &tmux.PaneInfo{ID: "%1", Width: 80, Height: 24}
+	got := listCapture(nil, pane)
+	assert.Empty(t, got.Text)
+	assert.Empty(t, got.Matches)
+	assert.Equal(t, pane, got.PaneOf("foo"))
+}
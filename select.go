@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/log"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+)
+
+// selectSpec specifies a match to select without showing the UI. It takes
+// the form NAME:INDEX, where NAME is the name of a regex, and INDEX is one of
+// 'first', 'last', a 1-based index from the top of the screen, or a negative
+// index from the bottom.
+type selectSpec struct {
+	Regex string
+	Index int // 1-based; negative counts from the end; 0 if unset
+}
+
+func (s *selectSpec) String() string {
+	if s.Index == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v:%d", s.Regex, s.Index)
+}
+
+func (s *selectSpec) Set(v string) error {
+	idx := strings.LastIndexByte(v, ':')
+	if idx <= 0 {
+		return errors.New("select must be in the form NAME:INDEX")
+	}
+
+	name, pos := v[:idx], v[idx+1:]
+	var n int
+	switch pos {
+	case "first":
+		n = 1
+	case "last":
+		n = -1
+	default:
+		var err error
+		n, err = strconv.Atoi(pos)
+		if err != nil || n == 0 {
+			return fmt.Errorf("invalid select index %q: "+
+				"must be first, last, or a non-zero integer", pos)
+		}
+	}
+
+	s.Regex = name
+	s.Index = n
+	return nil
+}
+
+// IsSet reports whether a selection was requested.
+func (s *selectSpec) IsSet() bool {
+	return s.Index != 0
+}
+
+// Pick picks the match requested by this spec from the given matches.
+// Matches are expected to be in the order they appear on the screen.
+func (s *selectSpec) Pick(text string, matches []fastcopy.Match) (fastcopy.Selection, bool) {
+	var candidates []fastcopy.Match
+	for _, m := range matches {
+		if m.Matcher == s.Regex {
+			candidates = append(candidates, m)
+		}
+	}
+
+	idx := s.Index - 1
+	if s.Index < 0 {
+		idx = len(candidates) + s.Index
+	}
+	if idx < 0 || idx >= len(candidates) {
+		return fastcopy.Selection{}, false
+	}
+
+	return newSelection(text, matches, candidates[idx].TextIn(text)), true
+}
+
+// directSelect selects text on the screen as specified by the -select flag
+// and runs the action on it. It does not show a UI, so unlike app, it
+// doesn't need to be wrapped in its own tmux session.
+type directSelect struct {
+	Stdout    io.Writer
+	Log       *log.Logger
+	Tmux      tmux.Driver
+	Version   tmux.Version
+	NewAction func(newActionRequest) (action, error)
+}
+
+// Run runs the selection with the provided configuration.
+func (s *directSelect) Run(cfg *config) error {
+	if err := cfg.loadOptions(s.Tmux); err != nil {
+		return err
+	}
+	cfg.FillFrom(defaultConfig())
+
+	if len(cfg.Regexes[cfg.Select.Regex]) == 0 {
+		return fmt.Errorf("select: regex %q is not defined", cfg.Select.Regex)
+	}
+
+	if cfg.HistoryLines < 0 {
+		return fmt.Errorf("history-lines must not be negative: %v", cfg.HistoryLines)
+	}
+
+	matcher, err := cfg.newMatcher()
+	if err != nil {
+		return err
+	}
+
+	targetPane, err := tmux.InspectPane(s.Tmux, cfg.Pane)
+	if err != nil {
+		return fmt.Errorf("inspect pane %q: %v", cfg.Pane, err)
+	}
+
+	captured, err := captureText(s.Tmux, s.Version, cfg, matcher, targetPane)
+	if err != nil {
+		return err
+	}
+
+	sel, ok := cfg.Select.Pick(captured.Text, captured.Matches)
+	if !ok {
+		return fmt.Errorf("select %v: no such match", cfg.Select.String())
+	}
+	s.Log.Debugf("selected %q", sel.Text)
+
+	if cfg.Print {
+		return printSelection(s.Stdout, cfg, sel)
+	}
+	return runAction(s.NewAction, cfg, captured.PaneOf(sel.Text), sel)
+}
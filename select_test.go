@@ -0,0 +1,180 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/log/logtest"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSelectSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give    string
+		want    selectSpec
+		wantStr string
+		wantErr string
+	}{
+		{give: "gitsha:first", want: selectSpec{"gitsha", 1}, wantStr: "gitsha:1"},
+		{give: "gitsha:last", want: selectSpec{"gitsha", -1}, wantStr: "gitsha:-1"},
+		{give: "url:3", want: selectSpec{"url", 3}, wantStr: "url:3"},
+		{give: "url:-2", want: selectSpec{"url", -2}, wantStr: "url:-2"},
+		{give: "a:b:1", want: selectSpec{"a:b", 1}, wantStr: "a:b:1"},
+		{give: "gitsha", wantErr: "must be in the form NAME:INDEX"},
+		{give: ":last", wantErr: "must be in the form NAME:INDEX"},
+		{give: "gitsha:0", wantErr: `invalid select index "0"`},
+		{give: "gitsha:middle", wantErr: `invalid select index "middle"`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			var got selectSpec
+			err := got.Set(tt.give)
+			if len(tt.wantErr) > 0 {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStr, got.String())
+		})
+	}
+}
+
+func TestSelectSpec_Pick(t *testing.T) {
+	t.Parallel()
+
+	// 0         1         2
+	// 0123456789012345678901234
+	// foo 12 bar 34 baz 56 qux
+	text := "foo 12 bar 34 baz 56 qux"
+	matches := []fastcopy.Match{
+		{Matcher: "word", Range: fastcopy.Range{Start: 0, End: 3}},
+		{Matcher: "int", Range: fastcopy.Range{Start: 4, End: 6}},
+		{Matcher: "word", Range: fastcopy.Range{Start: 7, End: 10}},
+		{Matcher: "int", Range: fastcopy.Range{Start: 11, End: 13}},
+		{Matcher: "int", Range: fastcopy.Range{Start: 18, End: 20}},
+	}
+
+	tests := []struct {
+		desc   string
+		give   selectSpec
+		want   string
+		wantOK bool
+	}{
+		{desc: "first", give: selectSpec{"int", 1}, want: "12", wantOK: true},
+		{desc: "second", give: selectSpec{"int", 2}, want: "34", wantOK: true},
+		{desc: "last", give: selectSpec{"int", -1}, want: "56", wantOK: true},
+		{desc: "second last", give: selectSpec{"int", -2}, want: "34", wantOK: true},
+		{desc: "other regex", give: selectSpec{"word", -1}, want: "bar", wantOK: true},
+		{desc: "out of range", give: selectSpec{"int", 4}},
+		{desc: "out of range/negative", give: selectSpec{"int", -4}},
+		{desc: "unknown regex", give: selectSpec{"uuid", 1}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := tt.give.Pick(text, matches)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got.Text)
+		})
+	}
+}
+
+func TestDirectSelect(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().ShowOptions(gomock.Any()).
+		Return([]byte("@fastcopy-action pbcopy\n"), nil)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: ""}).
+		Return([]byte("%1\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%1", JoinLines: true}).
+		Return([]byte("foo 1234 bar 5678 baz\n"), nil)
+
+	var (
+		gotReq newActionRequest
+		gotSel fastcopy.Selection
+	)
+	err := (&directSelect{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+		NewAction: func(req newActionRequest) (action, error) {
+			gotReq = req
+			return actionFunc(func(sel fastcopy.Selection) error {
+				gotSel = sel
+				return nil
+			}), nil
+		},
+	}).Run(&config{
+		Select: selectSpec{Regex: "int", Index: -1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, newActionRequest{
+		Action:       "pbcopy",
+		Dir:          "/home/user",
+		TargetPaneID: "%1",
+	}, gotReq)
+	assert.Equal(t, fastcopy.Selection{
+		Text:     "5678",
+		Matchers: []string{"int"},
+	}, gotSel)
+}
+
+func TestDirectSelect_noMatch(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+	tmuxDriver.EXPECT().
+		DisplayMessage(tmuxtest.DisplayMessageRequestMatcher{Pane: ""}).
+		Return([]byte("%1\t@1\t80\t24\tnormal-mode\t0\t0\t/home/user"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(gomock.Any()).
+		Return([]byte("nothing to see here\n"), nil)
+
+	err := (&directSelect{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+	}).Run(&config{
+		Select: selectSpec{Regex: "gitsha", Index: 1},
+	})
+	assert.ErrorContains(t, err, "select gitsha:1: no such match")
+}
+
+func TestDirectSelect_unknownRegex(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+
+	err := (&directSelect{
+		Log:  logtest.NewLogger(t),
+		Tmux: tmuxDriver,
+	}).Run(&config{
+		Select: selectSpec{Regex: "url", Index: 1},
+	})
+	assert.ErrorContains(t, err, `regex "url" is not defined`)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// userStateDir reports the directory in which tmux-fastcopy stores state
+// that persists between invocations.
+//
+// This is $XDG_STATE_HOME/tmux-fastcopy, falling back to
+// $HOME/.local/state/tmux-fastcopy.
+func userStateDir(getenv func(string) string) (string, error) {
+	if dir := getenv("XDG_STATE_HOME"); len(dir) > 0 {
+		return filepath.Join(dir, _name), nil
+	}
+
+	home := getenv("HOME")
+	if len(home) == 0 {
+		return "", errors.New("neither $XDG_STATE_HOME nor $HOME are set")
+	}
+	return filepath.Join(home, ".local", "state", _name), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/envtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStateDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		env     *envtest.Env
+		want    string
+		wantErr string
+	}{
+		{
+			desc: "xdg",
+			env: envtest.MustPairs(
+				"XDG_STATE_HOME", "/home/user/state",
+				"HOME", "/home/user",
+			),
+			want: "/home/user/state/tmux-fastcopy",
+		},
+		{
+			desc: "home",
+			env:  envtest.MustPairs("HOME", "/home/user"),
+			want: "/home/user/.local/state/tmux-fastcopy",
+		},
+		{
+			desc:    "neither",
+			env:     &envtest.Empty,
+			wantErr: "neither $XDG_STATE_HOME nor $HOME are set",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := userStateDir(tt.env.Getenv)
+			if len(tt.wantErr) > 0 {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
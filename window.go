@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/rivo/uniseg"
+)
+
+// captureScope specifies how much of tmux is searched for text.
+type captureScope string
+
+const (
+	// Only the target pane is searched.
+	captureScopePane captureScope = "pane"
+
+	// All visible panes in the window of the target pane are searched.
+	captureScopeWindow captureScope = "window"
+
+	// All panes in the session of the target pane are searched,
+	// and the matches are listed instead of shown in place.
+	captureScopeSession captureScope = "session"
+
+	// All panes on the tmux server are searched,
+	// and the matches are listed instead of shown in place.
+	captureScopeServer captureScope = "server"
+
+	// The tmux paste buffers are searched instead of panes.
+	captureScopeBuffers captureScope = "buffers"
+)
+
+func (s *captureScope) String() string {
+	return string(*s)
+}
+
+func (s *captureScope) Set(v string) error {
+	switch captureScope(v) {
+	case captureScopePane, captureScopeWindow, captureScopeSession,
+		captureScopeServer, captureScopeBuffers:
+		*s = captureScope(v)
+	default:
+		return fmt.Errorf("unknown scope %q: "+
+			"must be one of pane, window, session, server, or buffers", v)
+	}
+	return nil
+}
+
+// capture is text captured from tmux and the matches found in it.
+type capture struct {
+	Text    string
+	Matches []fastcopy.Match
+
+	// Size of the overlay needed to show the text the way it was on
+	// the screen.
+	Width, Height int
+
+	// Pane that the text was captured from. For the window scope, this
+	// is the target pane.
+	Pane *tmux.PaneInfo
+
+	// Whether the text covers the whole window.
+	Window bool
+
+	// Panes in which each of the matches was found,
+	// if the text came from more than one pane.
+	matchPanes []*tmux.PaneInfo
+}
+
+// captureText captures the text for the scope requested by cfg around the
+// target pane and finds matches in it.
+func captureText(
+	driver tmux.Driver,
+	version tmux.Version,
+	cfg *config,
+	m matcher,
+	targetPane *tmux.PaneInfo,
+) (*capture, error) {
+	switch cfg.Scope {
+	case captureScopeSession, captureScopeServer:
+		return captureList(driver, version, cfg, m, targetPane)
+	case captureScopeBuffers:
+		return captureBuffers(driver, m, targetPane)
+	}
+
+	if cfg.coversWindow(targetPane) {
+		panes, err := tmux.InspectWindow(driver, targetPane.WindowID)
+		if err != nil {
+			return nil, fmt.Errorf("inspect window %q: %v", targetPane.WindowID, err)
+		}
+
+		c, err := captureWindow(driver, version, m, panes)
+		if err != nil {
+			return nil, err
+		}
+		c.Pane = targetPane
+		c.Window = true
+		return c, nil
+	}
+
+	text, err := capturePane(driver, version, targetPane, cfg.HistoryLines)
+	if err != nil {
+		return nil, fmt.Errorf("capture pane %q: %v", cfg.Pane, err)
+	}
+	text, matches := m.MatchWrapped(text, targetPane.Width)
+	return &capture{
+		Text:    text,
+		Matches: matches,
+		Width:   targetPane.Width,
+		Height:  targetPane.Height,
+		Pane:    targetPane,
+	}, nil
+}
+
+// coversWindow reports whether the overlay for the given target pane should
+// cover its whole window.
+func (c *config) coversWindow(targetPane *tmux.PaneInfo) bool {
+	// Only the target pane is visible if the window is zoomed.
+	return c.Scope == captureScopeWindow && !targetPane.WindowZoomed
+}
+
+// PaneOf reports the pane that the given selected text was found in.
+//
+// If the text was found in more than one pane, this prefers the target pane.
+// Text that wasn't found in any one pane, like multiple selections joined
+// together, is attributed to the target pane.
+func (c *capture) PaneOf(text string) *tmux.PaneInfo {
+	var found *tmux.PaneInfo
+	for i, m := range c.Matches {
+		if i >= len(c.matchPanes) || m.TextIn(c.Text) != text {
+			continue
+		}
+		if pane := c.matchPanes[i]; pane.ID == c.Pane.ID {
+			return pane
+		} else if found == nil {
+			found = pane
+		}
+	}
+	if found == nil {
+		found = c.Pane
+	}
+	return found
+}
+
+// windowSize reports the size of the window that holds the given panes.
+func windowSize(panes []*tmux.PaneInfo) (width, height int) {
+	for _, p := range panes {
+		width = max(width, p.Left+p.Width)
+		height = max(height, p.Top+p.Height)
+	}
+	return width, height
+}
+
+// captureWindow captures the visible text of all the given panes of a window
+// and lays it out the way it appears on the screen, with borders between the
+// panes.
+//
+// Each pane is searched for matches separately so that matches don't run
+// across pane borders. Matches that wrap across rows of a pane are split
+// into a part for each row.
+func captureWindow(
+	driver tmux.Driver,
+	version tmux.Version,
+	m matcher,
+	panes []*tmux.PaneInfo,
+) (*capture, error) {
+	texts := make([]*paneText, len(panes))
+	for i, pane := range panes {
+		out, err := driver.CapturePane(newCapturePaneRequest(version, pane, 0))
+		if err != nil {
+			return nil, fmt.Errorf("capture pane %q: %v", pane.ID, err)
+		}
+
+		// Each row of the pane needs its own row in the window,
+		// so wrap the joined lines again.
+		text, matches := m.MatchWrapped(string(out), pane.Width)
+		rows := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+		rows = append(rows, make([]string, max(pane.Height-len(rows), 0))...)
+		rows = rows[:pane.Height]
+
+		offsets := make([]int, len(rows))
+		var off int
+		for r, row := range rows {
+			offsets[r] = off
+			off += len(row) + 1
+		}
+
+		texts[i] = &paneText{
+			Pane:          pane,
+			Rows:          rows,
+			Matches:       matches,
+			Offsets:       offsets,
+			WindowOffsets: make([]int, len(rows)),
+		}
+	}
+
+	width, height := windowSize(panes)
+	var sb strings.Builder
+	for y := range height {
+		for x := 0; x < width; {
+			pt := paneTextAt(texts, x, y)
+			if pt == nil {
+				sb.WriteString(borderAt(panes, x, y))
+				x++
+				continue
+			}
+
+			row := pt.Rows[y-pt.Pane.Top]
+			pt.WindowOffsets[y-pt.Pane.Top] = sb.Len()
+			sb.WriteString(row)
+			sb.WriteString(strings.Repeat(" ", max(pt.Pane.Width-uniseg.StringWidth(row), 0)))
+			x += pt.Pane.Width
+		}
+		sb.WriteString("\n")
+	}
+
+	type paneMatch struct {
+		Match fastcopy.Match
+		Pane  *tmux.PaneInfo
+	}
+	var matches []paneMatch
+	for _, pt := range texts {
+		for _, match := range pt.Matches {
+			if match, ok := pt.windowMatch(match); ok {
+				matches = append(matches, paneMatch{Match: match, Pane: pt.Pane})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Match.Range.Start < matches[j].Match.Range.Start
+	})
+
+	c := capture{
+		Text:       sb.String(),
+		Width:      width,
+		Height:     height,
+		Matches:    make([]fastcopy.Match, len(matches)),
+		matchPanes: make([]*tmux.PaneInfo, len(matches)),
+	}
+	for i, pm := range matches {
+		c.Matches[i] = pm.Match
+		c.matchPanes[i] = pm.Pane
+	}
+	return &c, nil
+}
+
+// paneText is the text captured from a single pane of a window.
+type paneText struct {
+	Pane    *tmux.PaneInfo
+	Rows    []string
+	Matches []fastcopy.Match
+
+	// Offsets of rows in the text of the pane, and in the text of the
+	// window.
+	Offsets, WindowOffsets []int
+}
+
+// windowMatch maps a match in the text of the pane to the text of the window.
+// It reports false if any part of the match doesn't fit in one of the rows of
+// the pane.
+func (pt *paneText) windowMatch(m fastcopy.Match) (fastcopy.Match, bool) {
+	var ok bool
+	if m.Range, ok = pt.windowRange(m.Range); !ok {
+		return m, false
+	}
+
+	if len(m.Wrapped) > 0 {
+		wrapped := make([]fastcopy.Range, len(m.Wrapped))
+		for i, r := range m.Wrapped {
+			if wrapped[i], ok = pt.windowRange(r); !ok {
+				return m, false
+			}
+		}
+		m.Wrapped = wrapped
+	}
+	return m, true
+}
+
+// windowRange maps a range in the text of the pane to the text of the window.
+// It reports false if the range doesn't fit in one of the rows of the pane.
+func (pt *paneText) windowRange(r fastcopy.Range) (fastcopy.Range, bool) {
+	// Find the row that the range starts on.
+	row := sort.Search(len(pt.Offsets), func(i int) bool {
+		return pt.Offsets[i] > r.Start
+	}) - 1
+	if end := pt.Offsets[row] + len(pt.Rows[row]); r.End > end {
+		// Rows that are next to each other in the pane
+		// aren't next to each other in the window.
+		return r, false
+	}
+
+	delta := pt.WindowOffsets[row] - pt.Offsets[row]
+	return fastcopy.Range{Start: r.Start + delta, End: r.End + delta}, true
+}
+
+// paneTextAt returns the text of the pane whose row y starts at column x,
+// or nil if there isn't one.
+func paneTextAt(texts []*paneText, x, y int) *paneText {
+	for _, t := range texts {
+		p := t.Pane
+		if p.Left == x && p.Top <= y && y < p.Top+p.Height {
+			return t
+		}
+	}
+	return nil
+}
+
+// borderAt returns the character for the pane border at the given position.
+func borderAt(panes []*tmux.PaneInfo, x, y int) string {
+	for _, p := range panes {
+		if p.Top <= y && y < p.Top+p.Height && (p.Left+p.Width == x || p.Left == x+1) {
+			return "│"
+		}
+	}
+	return "─"
+}
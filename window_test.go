@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/abhinav/tmux-fastcopy/internal/fastcopy"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux"
+	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCaptureScope(t *testing.T) {
+	t.Parallel()
+
+	var s captureScope
+	require.NoError(t, s.Set("window"))
+	assert.Equal(t, captureScopeWindow, s)
+	assert.Equal(t, "window", s.String())
+
+	require.NoError(t, s.Set("session"))
+	assert.Equal(t, captureScopeSession, s)
+
+	require.NoError(t, s.Set("buffers"))
+	assert.Equal(t, captureScopeBuffers, s)
+
+	assert.ErrorContains(t, s.Set("client"), `unknown scope "client"`)
+}
+
+func TestCaptureWindow(t *testing.T) {
+	t.Parallel()
+
+	// +--------+-----+
+	// | %1     | %2  |
+	// |        +-----+
+	// |        | %3  |
+	// +--------+-----+
+	panes := []*tmux.PaneInfo{
+		{ID: "%1", Width: 8, Height: 3},
+		{ID: "%2", Width: 5, Height: 1, Left: 9},
+		{ID: "%3", Width: 5, Height: 1, Left: 9, Top: 2},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	tmuxDriver := tmuxtest.NewMockDriver(mockCtrl)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%1", JoinLines: true}).
+		Return([]byte("a 1234\n5678 b 9876\n"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%2", JoinLines: true}).
+		Return([]byte("1234\n"), nil)
+	tmuxDriver.EXPECT().
+		CapturePane(tmux.CapturePaneRequest{Pane: "%3", JoinLines: true}).
+		Return([]byte("12 b\n"), nil)
+
+	m, err := newMatcher(regexes{
+		"int": `\d{4}`,
+		// Matches that run into the next line are dropped.
+		"eol": `b\n`,
+	})
+	require.NoError(t, err)
+
+	got, err := captureWindow(tmuxDriver, tmux.Version{Major: 3, Minor: 3}, m, panes)
+	require.NoError(t, err)
+
+	assert.Equal(t, 14, got.Width)
+	assert.Equal(t, 3, got.Height)
+	assert.Equal(t,
+		"a 1234  │1234 \n"+
+			"5678 b 9│─────\n"+
+			"876     │12 b \n",
+		got.Text)
+
+	var matched []string
+	for i, match := range got.Matches {
+		matched = append(matched, got.matchPanes[i].ID+":"+match.TextIn(got.Text))
+	}
+	assert.Equal(t, []string{"%1:1234", "%2:1234", "%1:5678", "%1:9876"}, matched)
+
+	// The match that wrapped is split at the pane border.
+	assert.Equal(t, []fastcopy.Range{{Start: 44, End: 47}}, got.Matches[3].Wrapped)
+}
+
+func TestCapture_PaneOf(t *testing.T) {
+	t.Parallel()
+
+	p1 := &tmux.PaneInfo{ID: "%1"}
+	p2 := &tmux.PaneInfo{ID: "%2"}
+	c := capture{
+		Text: "foo bar foo",
+		Matches: []fastcopy.Match{
+			{Matcher: "x", Range: fastcopy.Range{Start: 0, End: 3}},
+			{Matcher: "x", Range: fastcopy.Range{Start: 4, End: 7}},
+			{Matcher: "x", Range: fastcopy.Range{Start: 8, End: 11}},
+		},
+		Pane:       p1,
+		matchPanes: []*tmux.PaneInfo{p2, p2, p1},
+	}
+
+	assert.Same(t, p1, c.PaneOf("foo"), "must prefer the target pane")
+	assert.Same(t, p2, c.PaneOf("bar"))
+	assert.Same(t, p1, c.PaneOf("foo bar"), "must fall back to the target pane")
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/abhinav/tmux-fastcopy/internal/log"
+	"github.com/abhinav/tmux-fastcopy/internal/statefile"
 	"github.com/abhinav/tmux-fastcopy/internal/tail"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux"
 	"github.com/abhinav/tmux-fastcopy/internal/tmux/tmuxopt"
@@ -20,8 +24,10 @@ const (
 // wrapper wraps another function to ensure that it runs in its own tmux
 // session that it has full ownership of.
 type wrapper struct {
-	Tmux tmux.Driver
-	Log  *log.Logger
+	Tmux    tmux.Driver
+	Version tmux.Version
+	Log     *log.Logger
+	Stdout  io.Writer
 
 	Executable func() (string, error) // os.Executable
 	Getenv     func(string) string    // os.Getenv
@@ -86,42 +92,83 @@ func (w *wrapper) Run(cfg *config) (err error) {
 	cfg.LogFile = tmpLog.Name()
 	cfg.FillFrom(&tmuxCfg)
 
-	if destroyUnattached {
-		// If destroy-unattached is set, tmux-fastcopy's session
-		// will be terminated immediately upon spawning.
-		//
-		// We work around this by temporarily disabling the option
-		// and then re-enabling it after tmux-fastcopy exits.
-		req := tmux.SetOptionRequest{
-			Global: true,
-			Name:   "destroy-unattached",
-			Value:  "off",
+	// Problems with the alphabet are easy to miss in the logs,
+	// so show them in the status line.
+	if warnings := cfg.Alphabet.Warnings(); len(warnings) > 0 {
+		w.displayMessage(pane, strings.Join(warnings, "; "))
+	}
+
+	// The wrapped command reports how it went back to us through this
+	// file.
+	resultFile := tmpLog.Name() + ".result"
+	defer func() {
+		err = multierr.Append(err, statefile.Remove(resultFile))
+	}()
+
+	parent := strconv.Itoa(w.Getpid())
+	env := []string{
+		fmt.Sprintf("%v=%v", _parentPIDEnv, parent),
+		fmt.Sprintf("%v=%v", _resultFileEnv, resultFile),
+	}
+
+	if cfg.Popup && !w.Version.SupportsPopup() {
+		w.Log.Infof("popups require tmux 3.3 or newer, "+
+			"using a new session instead: tmux is %v", w.Version)
+		cfg.Popup = false
+	}
+
+	// The overlay covers the whole window for the window scope.
+	width, height := pane.Width, pane.Height
+	if cfg.coversWindow(pane) {
+		panes, err := tmux.InspectWindow(w.Tmux, pane.WindowID)
+		if err != nil {
+			return fmt.Errorf("inspect window %q: %v", pane.WindowID, err)
 		}
-		if err := w.Tmux.SetOption(req); err != nil {
-			return fmt.Errorf("set destroy-unattached=off: %v", err)
+		width, height = windowSize(panes)
+	}
+
+	if cfg.Popup {
+		if err := w.openPopup(pane, width, height, env, exe, cfg); err != nil {
+			// Fall back to swapping panes if we can't use popups.
+			w.Log.Errorf("unable to open popup, using a new session instead: %v", err)
+			cfg.Popup = false
 		}
+	}
 
-		req.Value = "on"
-		defer func(req tmux.SetOptionRequest) {
-			if setErr := w.Tmux.SetOption(req); setErr != nil {
-				err = multierr.Append(err, fmt.Errorf("set destroy-unattached=on: %v", setErr))
+	if !cfg.Popup {
+		if destroyUnattached {
+			// If destroy-unattached is set, tmux-fastcopy's session
+			// will be terminated immediately upon spawning.
+			//
+			// We work around this by temporarily disabling the option
+			// and then re-enabling it after tmux-fastcopy exits.
+			req := tmux.SetOptionRequest{
+				Global: true,
+				Name:   "destroy-unattached",
+				Value:  "off",
+			}
+			if err := w.Tmux.SetOption(req); err != nil {
+				return fmt.Errorf("set destroy-unattached=off: %v", err)
 			}
-		}(req)
 
-	}
+			req.Value = "on"
+			defer func(req tmux.SetOptionRequest) {
+				if setErr := w.Tmux.SetOption(req); setErr != nil {
+					err = multierr.Append(err, fmt.Errorf("set destroy-unattached=on: %v", setErr))
+				}
+			}(req)
+		}
 
-	parent := strconv.Itoa(w.Getpid())
-	req := tmux.NewSessionRequest{
-		Width:    pane.Width,
-		Height:   pane.Height,
-		Detached: true,
-		Env: []string{
-			fmt.Sprintf("%v=%v", _parentPIDEnv, w.Getpid()),
-		},
-		Command: append([]string{exe}, cfg.Flags()...),
-	}
-	if _, err := w.Tmux.NewSession(req); err != nil {
-		return err
+		req := tmux.NewSessionRequest{
+			Width:    width,
+			Height:   height,
+			Detached: true,
+			Env:      env,
+			Command:  append([]string{exe}, cfg.Flags()...),
+		}
+		if _, err := w.Tmux.NewSession(req); err != nil {
+			return err
+		}
 	}
 
 	logw := &log.Writer{Log: w.Log}
@@ -134,5 +181,82 @@ func (w *wrapper) Run(cfg *config) (err error) {
 		err = multierr.Append(err, tee.Stop())
 	}()
 
-	return w.Tmux.WaitForSignal(_signalPrefix + parent)
+	if err := w.Tmux.WaitForSignal(_signalPrefix + parent); err != nil {
+		return err
+	}
+
+	res, ok, err := readResult(resultFile)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// The wrapped command didn't get far enough to report a
+		// result. It probably panicked; the logs will say more.
+		res = result{
+			Status: statusError,
+			Error:  "exited unexpectedly without a result",
+		}
+	}
+	return w.report(pane, cfg, res)
+}
+
+// openPopup runs the wrapped command inside a borderless popup of the given
+// size placed exactly over the target pane, or over its window if the
+// overlay covers the whole window.
+func (w *wrapper) openPopup(pane *tmux.PaneInfo, width, height int, env []string, exe string, cfg *config) error {
+	x, y := "#{popup_pane_left}", "#{popup_pane_top}"
+	if cfg.coversWindow(pane) {
+		// Move from the top-left corner of the pane
+		// to the top-left corner of the window.
+		x = "#{e|-|:#{popup_pane_left},#{pane_left}}"
+		y = "#{e|-|:#{popup_pane_top},#{pane_top}}"
+	}
+
+	return w.Tmux.DisplayPopup(tmux.DisplayPopupRequest{
+		Pane:        pane.ID,
+		X:           x,
+		Y:           y,
+		Width:       width,
+		Height:      height,
+		NoBorder:    true,
+		CloseOnExit: true,
+		Env:         env,
+		Command:     append([]string{exe}, cfg.Flags()...),
+	})
+}
+
+// displayMessage shows a message in the status line of the client
+// displaying the given pane.
+func (w *wrapper) displayMessage(pane *tmux.PaneInfo, msg string) {
+	req := tmux.DisplayMessageRequest{
+		Pane:       pane.ID,
+		Message:    "tmux-fastcopy: " + strings.ReplaceAll(msg, "#", "##"),
+		StatusLine: true,
+	}
+	if _, err := w.Tmux.DisplayMessage(req); err != nil {
+		w.Log.Errorf("unable to display message: %v", err)
+	}
+}
+
+// report reports the result of the wrapped command to the user.
+func (w *wrapper) report(pane *tmux.PaneInfo, cfg *config, res result) error {
+	switch res.Status {
+	case statusError:
+		// Errors are easy to miss in the logs,
+		// so show them in the status line too.
+		w.displayMessage(pane, res.Error)
+		return errors.New(res.Error)
+
+	case statusCancelled:
+		if cfg.Print {
+			return _errCancelled
+		}
+		return nil
+
+	default:
+		if cfg.Print {
+			return printSelection(w.Stdout, cfg, res.Selection())
+		}
+		return nil
+	}
 }
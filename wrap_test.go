@@ -1,7 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
+	"io/fs"
+	"os"
 	"strings"
 	"testing"
 
@@ -65,9 +69,12 @@ func TestWrapper(t *testing.T) {
 		t.Run(tt.desc, func(t *testing.T) {
 			t.Parallel()
 
+			var resultFile string
 			mockTmux := tmuxtest.NewMockDriver(ctrl)
 			mockTmux.EXPECT().NewSession(gomock.Any()).
 				Do(func(req tmux.NewSessionRequest) {
+					resultFile = resultFileFromEnv(t, req.Env)
+
 					fset := flag.NewFlagSet(_name, flag.ContinueOnError)
 					fset.SetOutput(ioutil.TestLogWriter(t, ""))
 
@@ -84,7 +91,10 @@ func TestWrapper(t *testing.T) {
 					assert.Equal(t, tt.wantConfig, gotConfig)
 				})
 
-			mockTmux.EXPECT().WaitForSignal(gomock.Any())
+			mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+				DoAndReturn(func(string) error {
+					return writeResult(resultFile, result{Status: statusCancelled})
+				})
 			mockTmux.EXPECT().ShowOptions(gomock.Any()).
 				Return([]byte(strings.Join(tt.options, "\n")+"\n"), nil)
 
@@ -113,7 +123,12 @@ func TestWrapper_destroyUnattached(t *testing.T) {
 	mockTmux := tmuxtest.NewMockDriver(ctrl)
 	mockTmux.EXPECT().ShowOptions(gomock.Any()).
 		Return([]byte("destroy-unattached on\n"), nil)
-	mockTmux.EXPECT().WaitForSignal(gomock.Any())
+
+	var resultFile string
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{Status: statusCancelled})
+		})
 
 	// destroy-unattached is set to off before we create a new session,
 	// and set back to on after we create a new session.
@@ -123,7 +138,10 @@ func TestWrapper_destroyUnattached(t *testing.T) {
 			Name:   "destroy-unattached",
 			Value:  "off",
 		}).Return(nil),
-		mockTmux.EXPECT().NewSession(gomock.Any()),
+		mockTmux.EXPECT().NewSession(gomock.Any()).
+			Do(func(req tmux.NewSessionRequest) {
+				resultFile = resultFileFromEnv(t, req.Env)
+			}),
 		mockTmux.EXPECT().SetOption(tmux.SetOptionRequest{
 			Global: true,
 			Name:   "destroy-unattached",
@@ -149,3 +167,403 @@ func TestWrapper_destroyUnattached(t *testing.T) {
 	}
 	assert.NoError(t, w.Run(&config{}))
 }
+
+func TestWrapper_print(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+
+	var resultFile string
+	mockTmux.EXPECT().NewSession(gomock.Any()).
+		Do(func(req tmux.NewSessionRequest) {
+			resultFile = resultFileFromEnv(t, req.Env)
+			assert.Contains(t, req.Command, "-print")
+		})
+
+	// The wrapped command writes its result before signaling.
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{
+				Status:  statusSelected,
+				Text:    "deadbeef",
+				Regexes: []string{"gitsha"},
+			})
+		})
+
+	var stdout bytes.Buffer
+	w := wrapper{
+		Tmux:   mockTmux,
+		Log:    logtest.NewLogger(t),
+		Stdout: &stdout,
+		Executable: func() (string, error) {
+			return _name, nil
+		},
+		Getenv: envtest.Empty.Getenv,
+		Getpid: func() int { return 42 },
+		inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+			return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+		},
+	}
+	require.NoError(t, w.Run(&config{Print: true, PrintRegexName: true}))
+	assert.Equal(t, "gitsha\tdeadbeef\n", stdout.String())
+
+	_, err := os.Stat(resultFile)
+	assert.ErrorIs(t, err, fs.ErrNotExist, "result file must be deleted")
+}
+
+func TestWrapper_alphabetWarnings(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().ShowOptions(gomock.Any()).
+		Return([]byte("@fastcopy-alphabet abC\n"), nil)
+	mockTmux.EXPECT().DisplayMessage(tmux.DisplayMessageRequest{
+		Pane: "%1",
+		Message: `tmux-fastcopy: alphabet has uppercase letters: ['C']: ` +
+			"these are typed as lowercase letters with Shift pressed, " +
+			"so labels using these cannot be selected",
+		StatusLine: true,
+	})
+
+	var resultFile string
+	mockTmux.EXPECT().NewSession(gomock.Any()).
+		Do(func(req tmux.NewSessionRequest) {
+			resultFile = resultFileFromEnv(t, req.Env)
+		})
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{Status: statusCancelled})
+		})
+
+	w := wrapper{
+		Tmux: mockTmux,
+		Log:  logtest.NewLogger(t),
+		Executable: func() (string, error) {
+			return _name, nil
+		},
+		Getenv: envtest.Empty.Getenv,
+		Getpid: func() int { return 42 },
+		inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+			return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+		},
+	}
+	assert.NoError(t, w.Run(&config{}))
+}
+
+func TestWrapper_popup(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	// destroy-unattached doesn't matter because there's no new session.
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().ShowOptions(gomock.Any()).
+		Return([]byte("destroy-unattached on\n@fastcopy-popup on\n"), nil)
+
+	var resultFile string
+	mockTmux.EXPECT().DisplayPopup(gomock.Any()).
+		DoAndReturn(func(req tmux.DisplayPopupRequest) error {
+			resultFile = resultFileFromEnv(t, req.Env)
+			assert.Equal(t, "%1", req.Pane)
+			assert.Equal(t, 80, req.Width)
+			assert.Equal(t, 40, req.Height)
+			assert.True(t, req.NoBorder, "popup must not have a border")
+			assert.True(t, req.CloseOnExit, "popup must close on exit")
+			assert.Contains(t, req.Command, "-popup")
+			return nil
+		})
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{Status: statusCancelled})
+		})
+
+	w := wrapper{
+		Tmux:    mockTmux,
+		Version: tmux.Version{Major: 3, Minor: 3},
+		Log:     logtest.NewLogger(t),
+		Executable: func() (string, error) {
+			return _name, nil
+		},
+		Getenv: envtest.Empty.Getenv,
+		Getpid: func() int { return 42 },
+		inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+			return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+		},
+	}
+	assert.NoError(t, w.Run(&config{}))
+}
+
+func TestWrapper_windowScope(t *testing.T) {
+	t.Parallel()
+
+	newWrapper := func(t *testing.T, mockTmux *tmuxtest.MockDriver) *wrapper {
+		mockTmux.EXPECT().ListPanes(gomock.Any()).
+			DoAndReturn(func(req tmux.ListPanesRequest) ([]byte, error) {
+				assert.Equal(t, "@1", req.Window)
+				return []byte(
+					"%1\t@1\t40\t40\tnormal-mode\t0\t0\t/\t0\t0\n" +
+						"%2\t@1\t39\t40\tnormal-mode\t0\t0\t/\t41\t0\n"), nil
+			})
+
+		return &wrapper{
+			Tmux:    mockTmux,
+			Version: tmux.Version{Major: 3, Minor: 3},
+			Log:     logtest.NewLogger(t),
+			Executable: func() (string, error) {
+				return _name, nil
+			},
+			Getenv: envtest.Empty.Getenv,
+			Getpid: func() int { return 42 },
+			inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+				return &tmux.PaneInfo{ID: "%2", WindowID: "@1", Width: 39, Height: 40, Left: 41}, nil
+			},
+		}
+	}
+
+	t.Run("new session", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		mockTmux := tmuxtest.NewMockDriver(ctrl)
+		mockTmux.EXPECT().ShowOptions(gomock.Any()).
+			Return([]byte("@fastcopy-scope window\n"), nil)
+
+		var resultFile string
+		mockTmux.EXPECT().NewSession(gomock.Any()).
+			Do(func(req tmux.NewSessionRequest) {
+				resultFile = resultFileFromEnv(t, req.Env)
+				assert.Equal(t, 80, req.Width)
+				assert.Equal(t, 40, req.Height)
+			})
+		mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+			DoAndReturn(func(string) error {
+				return writeResult(resultFile, result{Status: statusCancelled})
+			})
+
+		assert.NoError(t, newWrapper(t, mockTmux).Run(&config{}))
+	})
+
+	t.Run("popup", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+		mockTmux := tmuxtest.NewMockDriver(ctrl)
+		mockTmux.EXPECT().ShowOptions(gomock.Any()).
+			Return([]byte("@fastcopy-popup on\n"), nil)
+
+		var resultFile string
+		mockTmux.EXPECT().DisplayPopup(gomock.Any()).
+			DoAndReturn(func(req tmux.DisplayPopupRequest) error {
+				resultFile = resultFileFromEnv(t, req.Env)
+				assert.Equal(t, "%2", req.Pane)
+				assert.Equal(t, 80, req.Width)
+				assert.Equal(t, 40, req.Height)
+				assert.Equal(t, "#{e|-|:#{popup_pane_left},#{pane_left}}", req.X)
+				assert.Equal(t, "#{e|-|:#{popup_pane_top},#{pane_top}}", req.Y)
+				return nil
+			})
+		mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+			DoAndReturn(func(string) error {
+				return writeResult(resultFile, result{Status: statusCancelled})
+			})
+
+		assert.NoError(t, newWrapper(t, mockTmux).Run(&config{Scope: captureScopeWindow}))
+	})
+}
+
+func TestWrapper_popupFallback(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+	mockTmux.EXPECT().DisplayPopup(gomock.Any()).
+		Return(errors.New("unknown command: display-popup"))
+
+	var resultFile string
+	mockTmux.EXPECT().NewSession(gomock.Any()).
+		Do(func(req tmux.NewSessionRequest) {
+			resultFile = resultFileFromEnv(t, req.Env)
+			assert.NotContains(t, req.Command, "-popup",
+				"wrapped command must not expect a popup")
+		})
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{Status: statusCancelled})
+		})
+
+	w := wrapper{
+		Tmux:    mockTmux,
+		Version: tmux.Version{Major: 3, Minor: 3},
+		Log:     logtest.NewLogger(t),
+		Executable: func() (string, error) {
+			return _name, nil
+		},
+		Getenv: envtest.Empty.Getenv,
+		Getpid: func() int { return 42 },
+		inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+			return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+		},
+	}
+	assert.NoError(t, w.Run(&config{Popup: true}))
+}
+
+func TestWrapper_popupOldTmux(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	// display-popup isn't even attempted.
+	mockTmux := tmuxtest.NewMockDriver(ctrl)
+	mockTmux.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+
+	var resultFile string
+	mockTmux.EXPECT().NewSession(gomock.Any()).
+		Do(func(req tmux.NewSessionRequest) {
+			resultFile = resultFileFromEnv(t, req.Env)
+			assert.NotContains(t, req.Command, "-popup")
+		})
+	mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+		DoAndReturn(func(string) error {
+			return writeResult(resultFile, result{Status: statusCancelled})
+		})
+
+	w := wrapper{
+		Tmux:    mockTmux,
+		Version: tmux.Version{Major: 3, Minor: 2},
+		Log:     logtest.NewLogger(t),
+		Executable: func() (string, error) {
+			return _name, nil
+		},
+		Getenv: envtest.Empty.Getenv,
+		Getpid: func() int { return 42 },
+		inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+			return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+		},
+	}
+	assert.NoError(t, w.Run(&config{Popup: true}))
+}
+
+// resultFileFromEnv extracts the path to the result file from the
+// environment passed to the wrapped command.
+func resultFileFromEnv(t *testing.T, env []string) string {
+	for _, e := range env {
+		if v, ok := strings.CutPrefix(e, _resultFileEnv+"="); ok {
+			return v
+		}
+	}
+	t.Fatalf("result file not found in %q", env)
+	return ""
+}
+
+func TestWrapper_report(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		print bool
+		give  *result // nil if no result was written
+
+		wantStdout  string
+		wantMessage string // message in the status line, if any
+		wantErr     string
+		wantCode    int // exit code for exitError
+	}{
+		{
+			desc: "selected",
+			give: &result{Status: statusSelected, Text: "foo"},
+		},
+		{
+			desc:       "selected/print",
+			print:      true,
+			give:       &result{Status: statusSelected, Text: "foo"},
+			wantStdout: "foo\n",
+		},
+		{
+			desc: "cancelled",
+			give: &result{Status: statusCancelled},
+		},
+		{
+			desc:     "cancelled/print",
+			print:    true,
+			give:     &result{Status: statusCancelled},
+			wantCode: 2,
+		},
+		{
+			desc:        "error",
+			give:        &result{Status: statusError, Error: "action #1 failed"},
+			wantMessage: "tmux-fastcopy: action ##1 failed",
+			wantErr:     "action #1 failed",
+		},
+		{
+			desc:        "no result",
+			wantMessage: "tmux-fastcopy: exited unexpectedly without a result",
+			wantErr:     "exited unexpectedly",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			mockTmux := tmuxtest.NewMockDriver(ctrl)
+			mockTmux.EXPECT().ShowOptions(gomock.Any()).Return([]byte{}, nil)
+
+			var resultFile string
+			mockTmux.EXPECT().NewSession(gomock.Any()).
+				Do(func(req tmux.NewSessionRequest) {
+					resultFile = resultFileFromEnv(t, req.Env)
+				})
+			mockTmux.EXPECT().WaitForSignal(gomock.Any()).
+				DoAndReturn(func(string) error {
+					if tt.give == nil {
+						return nil
+					}
+					return writeResult(resultFile, *tt.give)
+				})
+			if len(tt.wantMessage) > 0 {
+				mockTmux.EXPECT().DisplayMessage(tmux.DisplayMessageRequest{
+					Pane:       "%1",
+					Message:    tt.wantMessage,
+					StatusLine: true,
+				})
+			}
+
+			var stdout bytes.Buffer
+			w := wrapper{
+				Tmux:   mockTmux,
+				Log:    logtest.NewLogger(t),
+				Stdout: &stdout,
+				Executable: func() (string, error) {
+					return _name, nil
+				},
+				Getenv: envtest.Empty.Getenv,
+				Getpid: func() int { return 42 },
+				inspectPane: func(tmux.Driver, string) (*tmux.PaneInfo, error) {
+					return &tmux.PaneInfo{ID: "%1", Width: 80, Height: 40}, nil
+				},
+			}
+			err := w.Run(&config{Print: tt.print})
+			switch {
+			case len(tt.wantErr) > 0:
+				assert.ErrorContains(t, err, tt.wantErr)
+			case tt.wantCode != 0:
+				var exitErr *exitError
+				if assert.ErrorAs(t, err, &exitErr) {
+					assert.Equal(t, tt.wantCode, exitErr.Code)
+				}
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantStdout, stdout.String())
+		})
+	}
+}